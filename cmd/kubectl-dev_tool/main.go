@@ -11,6 +11,8 @@ import (
 	"github.com/openshift/cluster-debug-tools/pkg/cmd/audit"
 	"github.com/openshift/cluster-debug-tools/pkg/cmd/events"
 	"github.com/openshift/cluster-debug-tools/pkg/cmd/psa"
+	"github.com/openshift/cluster-debug-tools/pkg/cmd/query"
+	"github.com/openshift/cluster-debug-tools/pkg/cmd/timeline"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -54,6 +56,8 @@ func NewCmdDevHelpers(streams genericclioptions.IOStreams) *cobra.Command {
 	cmd.AddCommand(psa.NewCmdPSA("openshift-dev-helpers", streams))
 	cmd.AddCommand(events.NewCmdEvent("openshift-dev-helpers", streams))
 	cmd.AddCommand(audit.NewCmdAudit("openshift-dev-helpers", streams))
+	cmd.AddCommand(query.NewCmdQuery("openshift-dev-helpers", streams))
+	cmd.AddCommand(timeline.NewCmdTimeline("openshift-dev-helpers", streams))
 	cmd.AddCommand(mustgather.NewCmdRevisionStatus("openshift-dev-helpers", streams))
 	cmd.AddCommand(certs.NewCmdCerts(streams))
 	cmd.AddCommand(analyze_e2e.NewCmdAnalyze("openshift-dev-helpers", streams))
@@ -0,0 +1,331 @@
+package audit
+
+import (
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	"k8s.io/klog"
+)
+
+// minEventsPerShard keeps small inputs on a single goroutine, spawning workers is not worth it below this size.
+const minEventsPerShard = 10000
+
+// eventGroupKey identifies a group of equivalent requests issued by the same user within a bucket (verb, http status
+// code, etc.).
+type eventGroupKey[K comparable] struct {
+	bucket   K
+	uriKey   string
+	username string
+}
+
+// RequestGroup counts the equivalent requests of a user, Event is the first of them.
+type RequestGroup struct {
+	Event             *auditv1.Event
+	Username          string
+	Count             int64
+	StatusCodeToCount map[int32]int64
+	TotalDuration     time.Duration
+}
+
+func newRequestGroup(event *auditv1.Event, username string) *RequestGroup {
+	ret := &RequestGroup{
+		Event:             event,
+		Username:          username,
+		StatusCodeToCount: map[int32]int64{},
+	}
+	ret.addEvent(event)
+	return ret
+}
+
+func (g *RequestGroup) addEvent(event *auditv1.Event) {
+	g.Count++
+	g.TotalDuration += event.StageTimestamp.Time.Sub(event.RequestReceivedTimestamp.Time)
+	if event.ResponseStatus != nil {
+		g.StatusCodeToCount[event.ResponseStatus.Code] = g.StatusCodeToCount[event.ResponseStatus.Code] + 1
+	}
+}
+
+// merge folds the counters of other into g, the representative event of g is kept.
+func (g *RequestGroup) merge(other *RequestGroup) {
+	g.Count += other.Count
+	g.TotalDuration += other.TotalDuration
+	for code, count := range other.StatusCodeToCount {
+		g.StatusCodeToCount[code] = g.StatusCodeToCount[code] + count
+	}
+}
+
+// RequestGroups holds the equivalent request groups of a single bucket.
+type RequestGroups struct {
+	Total  int
+	Groups []*RequestGroup
+}
+
+// GroupRequests splits events into buckets and, within each bucket, aggregates requests by their canonical
+// URI key and field manager qualified username.  The events are sharded across goroutines and the partial results are
+// merged in the original order, so the representative event of every group is the first one seen.
+// Groups are returned sorted by count, highest first.
+func GroupRequests[K comparable](events []*auditv1.Event, bucketFn func(*auditv1.Event) K) map[K]*RequestGroups {
+	numShards := runtime.GOMAXPROCS(0)
+	if maxShards := len(events) / minEventsPerShard; maxShards < numShards {
+		numShards = maxShards
+	}
+	if numShards < 1 {
+		numShards = 1
+	}
+	shardSize := (len(events) + numShards - 1) / numShards
+
+	type shard struct {
+		order  []eventGroupKey[K]
+		groups map[eventGroupKey[K]]*RequestGroup
+	}
+	shards := make([]shard, numShards)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < numShards; i++ {
+		start := i * shardSize
+		end := start + shardSize
+		if end > len(events) {
+			end = len(events)
+		}
+		if start >= end {
+			continue
+		}
+
+		wg.Add(1)
+		go func(s *shard, events []*auditv1.Event) {
+			defer wg.Done()
+			s.groups = map[eventGroupKey[K]]*RequestGroup{}
+			for _, event := range events {
+				username := getFieldManagerQualifiedUsername(event)
+				key := eventGroupKey[K]{bucket: bucketFn(event), uriKey: auditURIKey(event.RequestURI), username: username}
+				if group, ok := s.groups[key]; ok {
+					group.addEvent(event)
+					continue
+				}
+				s.groups[key] = newRequestGroup(event, username)
+				s.order = append(s.order, key)
+			}
+		}(&shards[i], events[start:end])
+	}
+	wg.Wait()
+
+	// merge in shard order so that the earliest event stays the representative of a group
+	merged := map[eventGroupKey[K]]*RequestGroup{}
+	ret := map[K]*RequestGroups{}
+	for _, s := range shards {
+		for _, key := range s.order {
+			group := s.groups[key]
+			if existing, ok := merged[key]; ok {
+				existing.merge(group)
+				continue
+			}
+			merged[key] = group
+
+			if _, ok := ret[key.bucket]; !ok {
+				ret[key.bucket] = &RequestGroups{}
+			}
+			ret[key.bucket].Groups = append(ret[key.bucket].Groups, group)
+		}
+	}
+
+	for _, bucket := range ret {
+		for _, group := range bucket.Groups {
+			bucket.Total += int(group.Count)
+		}
+		sort.SliceStable(bucket.Groups, func(i, j int) bool {
+			return bucket.Groups[i].Count > bucket.Groups[j].Count
+		})
+	}
+
+	return ret
+}
+
+// NamedCount is the number of requests of a user, a resource, a namespace, etc.
+type NamedCount struct {
+	Name  string
+	Count int
+}
+
+// sortNamedCounts sorts by count, highest first.
+func sortNamedCounts(counts map[string]int) []NamedCount {
+	ret := []NamedCount{}
+	for name, count := range counts {
+		ret = append(ret, NamedCount{Name: name, Count: count})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Count != ret[j].Count {
+			return ret[i].Count > ret[j].Count
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// CountByUser counts the requests per field manager qualified username.
+func CountByUser(events []*auditv1.Event) []NamedCount {
+	counts := map[string]int{}
+	for _, event := range events {
+		counts[getFieldManagerQualifiedUsername(event)]++
+	}
+	return sortNamedCounts(counts)
+}
+
+// CountByNamespace counts the requests per namespace, cluster scoped requests are counted under "".
+func CountByNamespace(events []*auditv1.Event) []NamedCount {
+	counts := map[string]int{}
+	for _, event := range events {
+		namespace, _, _, _ := URIToParts(event.RequestURI)
+		counts[namespace]++
+	}
+	return sortNamedCounts(counts)
+}
+
+// CountByResource counts the requests per group version and resource, eg. v1/secrets or apps/v1/deployments.
+func CountByResource(events []*auditv1.Event) []NamedCount {
+	counts := map[string]int{}
+	for _, event := range events {
+		noParamsUri := strings.Split(event.RequestURI, "?")
+		uri := strings.Split(strings.TrimPrefix(noParamsUri[0], "/"), "/")
+		if len(uri) == 0 {
+			continue
+		}
+
+		switch uri[0] {
+		// kube api
+		case "api":
+			switch len(uri) {
+			case 1, 2:
+				continue
+			case 3:
+				// /api/v1/nodes -> v1/nodes
+				counts[strings.Join(uri[1:3], "/")]++
+			default:
+				// /api/v1/namespaces/foo/secrets -> v1/secrets
+				if uri[2] == "namespaces" && len(uri) >= 5 {
+					counts[uri[1]+"/"+uri[4]]++
+					continue
+				}
+				counts[strings.Join(uri[1:3], "/")]++
+			}
+		case "apis":
+			switch len(uri) {
+			case 1, 2, 3:
+				continue
+			case 4:
+				counts[strings.Join(uri[1:4], "/")]++
+			default:
+				if uri[3] == "namespaces" && len(uri) >= 6 {
+					counts[uri[1]+"/"+uri[5]]++
+					continue
+				}
+				counts[strings.Join(uri[1:4], "/")]++
+			}
+		}
+	}
+	return sortNamedCounts(counts)
+}
+
+// CountByKind counts the requests per kind and scope, the resources discovery does not know are counted by resource.
+func CountByKind(events []*auditv1.Event, resolver *ResourceResolver) []NamedCount {
+	counts := map[string]int{}
+	for _, event := range events {
+		_, gvr, _, _ := resolver.URIToParts(event.RequestURI)
+		if len(gvr.Resource) == 0 {
+			continue
+		}
+		kind := resolver.KindFor(gvr.GroupResource())
+		if resource, ok := resolver.Get(gvr.GroupResource()); ok {
+			switch resource.Scope {
+			case meta.RESTScopeNameNamespace:
+				kind = kind + " (namespaced)"
+			case meta.RESTScopeNameRoot:
+				kind = kind + " (cluster)"
+			}
+		}
+		counts[kind]++
+	}
+	return sortNamedCounts(counts)
+}
+
+// LatencyTrackerSummary summarizes the values of one apiserver.latency.k8s.io/ annotation.
+type LatencyTrackerSummary struct {
+	Name                  string
+	Min, Max, Median, P90 time.Duration
+}
+
+// SummarizeLatencyTrackers summarizes the latency tracker annotations of the events, sorted by name.  Values that are
+// not durations are skipped.
+func SummarizeLatencyTrackers(events []*auditv1.Event) []LatencyTrackerSummary {
+	latencyTrackers := map[string][]time.Duration{}
+	for _, event := range events {
+		for latencyTracker, latencyValue := range event.Annotations {
+			if !strings.HasPrefix(latencyTracker, "apiserver.latency.k8s.io/") {
+				continue
+			}
+
+			latencyDuration, err := time.ParseDuration(latencyValue)
+			if err != nil {
+				klog.V(1).Infof("Error parsing %q=%v duration, for an event with auditID=%v, err=%v", latencyTracker, latencyValue, event.AuditID, err)
+				continue
+			}
+			latencyTrackers[latencyTracker] = append(latencyTrackers[latencyTracker], latencyDuration)
+		}
+	}
+
+	ret := []LatencyTrackerSummary{}
+	for latencyTracker, latencies := range latencyTrackers {
+		sort.Slice(latencies, func(i, j int) bool {
+			return latencies[i] < latencies[j]
+		})
+		min, max, median, p90 := statsForLatencyTrackers(90, latencies)
+		ret = append(ret, LatencyTrackerSummary{Name: latencyTracker, Min: min, Max: max, Median: median, P90: p90})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+func statsForLatencyTrackers(percentile float64, latencies []time.Duration) (time.Duration, time.Duration, time.Duration, time.Duration) {
+	if len(latencies) <= 1 {
+		return time.Duration(0), time.Duration(0), time.Duration(0), time.Duration(0)
+	}
+
+	isWholeNumberFn := func(num float64) bool {
+		return num == math.Floor(num)
+	}
+	meanFn := func(latency1, latency2 time.Duration) time.Duration {
+		latency1Ns := latency1.Nanoseconds()
+		latency2Ns := latency2.Nanoseconds()
+		meanLatencyNs := (latency1Ns + latency2Ns) / 2
+		return time.Duration(meanLatencyNs)
+	}
+	medianFn := func(latencies []time.Duration) time.Duration {
+		var median time.Duration
+		if len(latencies)%2 == 0 {
+			latencies = latencies[len(latencies)/2-1 : len(latencies)/2+1]
+			median = meanFn(latencies[0], latencies[1])
+		} else {
+			median = latencies[len(latencies)/2]
+		}
+		return median
+	}
+	percentileFn := func(percentile float64, latencies []time.Duration) time.Duration {
+		indexForPercentile := (percentile / 100.0) * float64(len(latencies))
+		if isWholeNumberFn(indexForPercentile) {
+			return latencies[int(indexForPercentile)]
+		}
+		if indexForPercentile > 1 {
+			return meanFn(latencies[int(indexForPercentile)-1], latencies[int(indexForPercentile)])
+		}
+		return latencies[0]
+	}
+
+	return latencies[0], latencies[len(latencies)-1], medianFn(latencies), percentileFn(percentile, latencies)
+}
@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestAuditURIKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		uri      string
+		expected string
+	}{
+		{
+			name:     "no query",
+			uri:      "/api/v1/namespaces/foo/pods",
+			expected: "/api/v1/namespaces/foo/pods",
+		},
+		{
+			name:     "only ignored params",
+			uri:      "/api/v1/pods?resourceVersion=123&timeoutSeconds=300&timeout=5m&continue=abc",
+			expected: "/api/v1/pods",
+		},
+		{
+			name:     "significant params are sorted",
+			uri:      "/api/v1/pods?watch=true&labelSelector=app%3Dfoo&resourceVersion=123",
+			expected: "/api/v1/pods?labelSelector=app%3Dfoo&watch=true",
+		},
+		{
+			name:     "unparsable query",
+			uri:      "/api/v1/pods?labelSelector=%zz",
+			expected: "/api/v1/pods?labelSelector=%zz",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := auditURIKey(tc.uri); actual != tc.expected {
+				t.Errorf("expected: %q, actual: %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsEquivalentAuditURI(t *testing.T) {
+	tests := []struct {
+		name     string
+		lhs, rhs string
+		expected bool
+	}{
+		{
+			name:     "different resource versions",
+			lhs:      "/api/v1/pods?watch=true&resourceVersion=1",
+			rhs:      "/api/v1/pods?resourceVersion=2&watch=true",
+			expected: true,
+		},
+		{
+			name:     "different selectors",
+			lhs:      "/api/v1/pods?labelSelector=a",
+			rhs:      "/api/v1/pods?labelSelector=b",
+			expected: false,
+		},
+		{
+			name:     "query only on one side",
+			lhs:      "/api/v1/pods?watch=true",
+			rhs:      "/api/v1/pods",
+			expected: false,
+		},
+		{
+			name:     "different paths",
+			lhs:      "/api/v1/pods",
+			rhs:      "/api/v1/nodes",
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := IsEquivalentAuditURI(tc.lhs, tc.rhs); actual != tc.expected {
+				t.Errorf("expected: %v, actual: %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGroupEventsByURIAndUser(t *testing.T) {
+	newEvent := func(verb, uri, user string) *auditv1.Event {
+		return &auditv1.Event{Verb: verb, RequestURI: uri, User: authnv1.UserInfo{Username: user}}
+	}
+	events := []*auditv1.Event{
+		newEvent("watch", "/api/v1/pods?watch=true&resourceVersion=1", "alice"),
+		newEvent("watch", "/api/v1/pods?watch=true&resourceVersion=2", "alice"),
+		newEvent("watch", "/api/v1/pods?watch=true&resourceVersion=3", "bob"),
+		newEvent("get", "/api/v1/namespaces/foo/pods/bar", "alice"),
+		newEvent("watch", "/api/v1/pods?watch=true&resourceVersion=4", "alice"),
+	}
+
+	result := GroupRequests(events, func(event *auditv1.Event) string { return event.Verb })
+	if len(result) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(result))
+	}
+
+	watches := result["watch"]
+	if watches.Total != 4 {
+		t.Errorf("expected 4 watches, got %d", watches.Total)
+	}
+	if len(watches.Groups) != 2 {
+		t.Fatalf("expected 2 watch groups, got %d", len(watches.Groups))
+	}
+	if watches.Groups[0].Username != "alice" || watches.Groups[0].Count != 3 {
+		t.Errorf("expected alice with 3 watches first, got %s with %d", watches.Groups[0].Username, watches.Groups[0].Count)
+	}
+	if watches.Groups[0].Event != events[0] {
+		t.Errorf("expected the first event to represent the group, got %q", watches.Groups[0].Event.RequestURI)
+	}
+}
+
+func TestGroupRequestsStatusCodes(t *testing.T) {
+	newEvent := func(code int32) *auditv1.Event {
+		return &auditv1.Event{
+			Verb:           "get",
+			RequestURI:     "/api/v1/namespaces/foo/pods/bar",
+			User:           authnv1.UserInfo{Username: "alice"},
+			ResponseStatus: &metav1.Status{Code: code},
+		}
+	}
+
+	// the small input is grouped on one goroutine, the large one is sharded and merged
+	for _, size := range []int{3, 3 * minEventsPerShard} {
+		events := []*auditv1.Event{}
+		expected := map[int32]int64{}
+		for i := 0; i < size; i++ {
+			code := int32(200)
+			if i%3 == 2 {
+				code = 404
+			}
+			events = append(events, newEvent(code))
+			expected[code]++
+		}
+
+		groups := GroupRequests(events, func(event *auditv1.Event) string { return event.Verb })["get"].Groups
+		if len(groups) != 1 {
+			t.Fatalf("expected a single group of %d events, got %d", size, len(groups))
+		}
+		// the status code of the first event of the group is counted too
+		if !reflect.DeepEqual(expected, groups[0].StatusCodeToCount) {
+			t.Errorf("expected %v for %d events, got %v", expected, size, groups[0].StatusCodeToCount)
+		}
+		if groups[0].Count != int64(size) {
+			t.Errorf("expected %d events, got %d", size, groups[0].Count)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package audit
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+// MinRecreations is the number of creations of the same name within the log that flags a name as recreated.
+const MinRecreations = 3
+
+// ObjectLifecycle is one incarnation of an object, either of the timestamps is zero when it is outside of the log.
+type ObjectLifecycle struct {
+	Created time.Time
+	Creator string
+	Deleted time.Time
+	Deleter string
+}
+
+// Lifespan is only known for the objects that were both created and deleted within the log.
+func (l *ObjectLifecycle) Lifespan() (time.Duration, bool) {
+	if l.Created.IsZero() || l.Deleted.IsZero() {
+		return 0, false
+	}
+	return l.Deleted.Sub(l.Created), true
+}
+
+// ObjectChurn holds the lifecycles of all the objects of a resource in a namespace.
+type ObjectChurn struct {
+	Resource   schema.GroupResource
+	Namespace  string
+	Creates    int
+	Deletes    int
+	Lifespans  []time.Duration
+	ShortLived int
+	Creators   sets.String
+	Deleters   sets.String
+}
+
+// RecreatedObject is a name that was created again and again, usually by a controller fighting with someone.
+type RecreatedObject struct {
+	Resource   schema.GroupResource
+	Namespace  string
+	Name       string
+	Lifecycles []*ObjectLifecycle
+}
+
+type objectKey struct {
+	resource  schema.GroupResource
+	namespace string
+	name      string
+}
+
+// BuildObjectChurn pairs the successful creates of each object with its successful deletes.  Deletes are graceful for
+// pods, so a lifecycle ends with the last delete before the next create while the deleter is the user of the first
+// one, the kubelet only finishes what someone else asked for.  Lifespans shorter than shortLived are counted as short
+// lived.
+func BuildObjectChurn(events []*auditv1.Event, shortLived time.Duration) ([]*ObjectChurn, []*RecreatedObject) {
+	lifecycles := map[objectKey][]*ObjectLifecycle{}
+	keys := []objectKey{}
+	for _, event := range events {
+		if event.Verb != "create" && event.Verb != "delete" {
+			continue
+		}
+		if code := ResponseCode(event); code < 200 || code >= 300 {
+			continue
+		}
+		ns, gvr, name, subresource := URIToParts(event.RequestURI)
+		if len(subresource) > 0 || len(gvr.Resource) == 0 {
+			// evictions and bindings are created as subresources
+			continue
+		}
+		if gvr.Group == "" && gvr.Resource == "namespaces" {
+			// namespaces are cluster scoped, /api/v1/namespaces/<name> is not in the namespace of the same name
+			ns = ""
+		}
+		if event.ObjectRef != nil && len(event.ObjectRef.Name) > 0 {
+			name = event.ObjectRef.Name
+		}
+		if len(name) == 0 {
+			name = createdObjectName(event)
+		}
+		if len(name) == 0 {
+			continue
+		}
+
+		key := objectKey{resource: gvr.GroupResource(), namespace: ns, name: name}
+		if _, ok := lifecycles[key]; !ok {
+			keys = append(keys, key)
+		}
+		timestamp := event.RequestReceivedTimestamp.Time
+		current := lifecycles[key]
+		switch event.Verb {
+		case "create":
+			lifecycles[key] = append(current, &ObjectLifecycle{Created: timestamp, Creator: event.User.Username})
+		case "delete":
+			if len(current) == 0 {
+				// created before the log starts
+				current = append(current, &ObjectLifecycle{})
+				lifecycles[key] = current
+			}
+			last := current[len(current)-1]
+			if len(last.Deleter) == 0 {
+				last.Deleter = event.User.Username
+			}
+			last.Deleted = timestamp
+		}
+	}
+
+	churns := map[string]*ObjectChurn{}
+	recreated := []*RecreatedObject{}
+	for _, key := range keys {
+		churnKey := key.resource.String() + "/" + key.namespace
+		churn, ok := churns[churnKey]
+		if !ok {
+			churn = &ObjectChurn{
+				Resource:  key.resource,
+				Namespace: key.namespace,
+				Creators:  sets.NewString(),
+				Deleters:  sets.NewString(),
+			}
+			churns[churnKey] = churn
+		}
+
+		creates := 0
+		for _, lifecycle := range lifecycles[key] {
+			if !lifecycle.Created.IsZero() {
+				creates++
+				churn.Creates++
+				churn.Creators.Insert(lifecycle.Creator)
+			}
+			if !lifecycle.Deleted.IsZero() {
+				churn.Deletes++
+				churn.Deleters.Insert(lifecycle.Deleter)
+			}
+			if lifespan, ok := lifecycle.Lifespan(); ok {
+				churn.Lifespans = append(churn.Lifespans, lifespan)
+				if lifespan < shortLived {
+					churn.ShortLived++
+				}
+			}
+		}
+		if creates >= MinRecreations {
+			recreated = append(recreated, &RecreatedObject{
+				Resource:   key.resource,
+				Namespace:  key.namespace,
+				Name:       key.name,
+				Lifecycles: lifecycles[key],
+			})
+		}
+	}
+
+	ret := []*ObjectChurn{}
+	for _, churn := range churns {
+		if churn.Creates == 0 && churn.Deletes == 0 {
+			continue
+		}
+		sort.Slice(churn.Lifespans, func(i, j int) bool {
+			return churn.Lifespans[i] < churn.Lifespans[j]
+		})
+		ret = append(ret, churn)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Creates+ret[i].Deletes != ret[j].Creates+ret[j].Deletes {
+			return ret[i].Creates+ret[i].Deletes > ret[j].Creates+ret[j].Deletes
+		}
+		return ret[i].Resource.String()+"/"+ret[i].Namespace < ret[j].Resource.String()+"/"+ret[j].Namespace
+	})
+	sort.SliceStable(recreated, func(i, j int) bool {
+		return len(recreated[i].Lifecycles) > len(recreated[j].Lifecycles)
+	})
+	return ret, recreated
+}
+
+// createdObjectName reads the name of a created object from the response, the request may only have a generateName.
+func createdObjectName(event *auditv1.Event) string {
+	for _, unknown := range []*runtime.Unknown{event.ResponseObject, event.RequestObject} {
+		if unknown == nil {
+			continue
+		}
+		object := &metav1.PartialObjectMetadata{}
+		if err := json.Unmarshal(unknown.Raw, object); err == nil && len(object.Name) > 0 {
+			return object.Name
+		}
+	}
+	return ""
+}
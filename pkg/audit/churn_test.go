@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestBuildObjectChurn(t *testing.T) {
+	start := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	newEvent := func(verb, uri, user string, offset time.Duration, code int32) *auditv1.Event {
+		return &auditv1.Event{
+			Verb:                     verb,
+			RequestURI:               uri,
+			User:                     authnv1.UserInfo{Username: user},
+			RequestReceivedTimestamp: metav1.NewMicroTime(start.Add(offset)),
+			ResponseStatus:           &metav1.Status{Code: code},
+		}
+	}
+	// creates are logged with the URI of the collection, the name is in the object reference unless it is generated
+	newCreate := func(uri, name, user string, offset time.Duration) *auditv1.Event {
+		event := newEvent("create", uri, user, offset, 201)
+		event.ObjectRef = &auditv1.ObjectReference{Name: name}
+		return event
+	}
+	newGeneratedCreate := func(uri, name, user string, offset time.Duration) *auditv1.Event {
+		event := newEvent("create", uri, user, offset, 201)
+		event.ObjectRef = &auditv1.ObjectReference{}
+		event.ResponseObject = &runtime.Unknown{Raw: []byte(fmt.Sprintf(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":%q,"generateName":"web-"}}`, name))}
+		return event
+	}
+
+	events := []*auditv1.Event{
+		// a graceful pod delete, the kubelet deletes the pod once its containers stopped
+		newGeneratedCreate("/api/v1/namespaces/foo/pods", "web-x2x4k", "replicaset-controller", 0),
+		newEvent("delete", "/api/v1/namespaces/foo/pods/web-x2x4k", "alice", 10*time.Second, 200),
+		newEvent("delete", "/api/v1/namespaces/foo/pods/web-x2x4k", "system:node:worker-1", 40*time.Second, 200),
+		// created before the log starts
+		newEvent("delete", "/api/v1/namespaces/foo/configmaps/old", "bob", 5*time.Second, 200),
+		// failed requests are not part of a lifecycle
+		newEvent("create", "/api/v1/namespaces/foo/configmaps", "bob", 6*time.Second, 409),
+		// namespaces are created in the collection and deleted by name
+		newCreate("/api/v1/namespaces", "e2e-test", "e2e", 0),
+		newEvent("delete", "/api/v1/namespaces/e2e-test", "e2e", 30*time.Second, 200),
+	}
+	for i := 0; i < MinRecreations; i++ {
+		offset := time.Duration(i) * 10 * time.Minute
+		events = append(events,
+			newCreate("/api/v1/namespaces/foo/pods", "flaky", "operator", offset),
+			newEvent("delete", "/api/v1/namespaces/foo/pods/flaky", "operator", offset+5*time.Minute, 200),
+		)
+	}
+
+	churns, recreated := BuildObjectChurn(events, time.Minute)
+	if len(churns) != 3 {
+		t.Fatalf("expected pods, configmaps and namespaces, got %d churns", len(churns))
+	}
+
+	pods := churns[0]
+	if pods.Resource != (schema.GroupResource{Resource: "pods"}) || pods.Namespace != "foo" {
+		t.Fatalf("expected pods in foo first, got %s in %s", pods.Resource, pods.Namespace)
+	}
+	if pods.Creates != 1+MinRecreations || pods.Deletes != 1+MinRecreations {
+		t.Errorf("expected %d creates and deletes, got %d creates and %d deletes", 1+MinRecreations, pods.Creates, pods.Deletes)
+	}
+	if pods.ShortLived != 1 || pods.Lifespans[0] != 40*time.Second {
+		t.Errorf("expected the graceful delete to end a 40s lifecycle, got %v", pods.Lifespans)
+	}
+	if !pods.Deleters.Has("alice") || pods.Deleters.Has("system:node:worker-1") {
+		t.Errorf("expected alice to be the deleter rather than the kubelet, got %v", pods.Deleters.List())
+	}
+
+	namespaces := churns[1]
+	if namespaces.Resource != (schema.GroupResource{Resource: "namespaces"}) || namespaces.Namespace != "" {
+		t.Fatalf("expected cluster scoped namespaces second, got %s in %q", namespaces.Resource, namespaces.Namespace)
+	}
+	if namespaces.Creates != 1 || namespaces.Deletes != 1 || len(namespaces.Lifespans) != 1 || namespaces.Lifespans[0] != 30*time.Second {
+		t.Errorf("expected the namespace create and delete to pair, got %d creates, %d deletes and %v", namespaces.Creates, namespaces.Deletes, namespaces.Lifespans)
+	}
+
+	configMaps := churns[2]
+	if configMaps.Creates != 0 || configMaps.Deletes != 1 || len(configMaps.Lifespans) != 0 {
+		t.Errorf("expected a delete without lifespan, got %d creates, %d deletes and %v", configMaps.Creates, configMaps.Deletes, configMaps.Lifespans)
+	}
+
+	if len(recreated) != 1 {
+		t.Fatalf("expected one recreated object, got %d", len(recreated))
+	}
+	if recreated[0].Name != "flaky" || len(recreated[0].Lifecycles) != MinRecreations {
+		t.Errorf("expected flaky to be recreated %d times, got %q with %d lifecycles", MinRecreations, recreated[0].Name, len(recreated[0].Lifecycles))
+	}
+}
@@ -0,0 +1,325 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog"
+)
+
+// DiscoveryFromCluster is the --discovery value that reads the discovery of the cluster of the kubeconfig.
+const DiscoveryFromCluster = "cluster"
+
+// DiscoveredResource is what discovery knows about a resource.
+type DiscoveredResource struct {
+	GroupResource schema.GroupResource
+	Kind          string
+	Singular      string
+	ShortNames    []string
+	Categories    []string
+	// Scope is meta.RESTScopeNameNamespace or meta.RESTScopeNameRoot, it is empty when it is not known.
+	Scope meta.RESTScopeName
+}
+
+// ResourceResolver maps the kinds, short names and categories users type to the resources in the request URIs.
+type ResourceResolver struct {
+	resources map[schema.GroupResource]*DiscoveredResource
+}
+
+func newResourceResolver() *ResourceResolver {
+	return &ResourceResolver{resources: map[schema.GroupResource]*DiscoveredResource{}}
+}
+
+// add keeps the first resource seen, snapshots may contain several versions of the same group.
+func (r *ResourceResolver) add(resource *DiscoveredResource) {
+	if len(resource.GroupResource.Resource) == 0 || strings.Contains(resource.GroupResource.Resource, "/") {
+		// subresources are listed as <resource>/<subresource>
+		return
+	}
+	if len(resource.Singular) == 0 {
+		resource.Singular = strings.ToLower(resource.Kind)
+	}
+	if existing, ok := r.resources[resource.GroupResource]; ok {
+		if len(existing.Scope) == 0 {
+			r.resources[resource.GroupResource] = resource
+		}
+		return
+	}
+	r.resources[resource.GroupResource] = resource
+}
+
+func (r *ResourceResolver) addAPIResourceList(list *metav1.APIResourceList) error {
+	gv, err := schema.ParseGroupVersion(list.GroupVersion)
+	if err != nil {
+		return err
+	}
+	for _, apiResource := range list.APIResources {
+		scope := meta.RESTScopeNameRoot
+		if apiResource.Namespaced {
+			scope = meta.RESTScopeNameNamespace
+		}
+		r.add(&DiscoveredResource{
+			GroupResource: schema.GroupResource{Group: gv.Group, Resource: apiResource.Name},
+			Kind:          apiResource.Kind,
+			Singular:      apiResource.SingularName,
+			ShortNames:    apiResource.ShortNames,
+			Categories:    apiResource.Categories,
+			Scope:         scope,
+		})
+	}
+	return nil
+}
+
+func (r *ResourceResolver) addCustomResourceDefinition(crd *unstructured.Unstructured) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	singular, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "singular")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	shortNames, _, _ := unstructured.NestedStringSlice(crd.Object, "spec", "names", "shortNames")
+	categories, _, _ := unstructured.NestedStringSlice(crd.Object, "spec", "names", "categories")
+	scope := meta.RESTScopeNameRoot
+	if crdScope, _, _ := unstructured.NestedString(crd.Object, "spec", "scope"); crdScope == "Namespaced" {
+		scope = meta.RESTScopeNameNamespace
+	}
+	r.add(&DiscoveredResource{
+		GroupResource: schema.GroupResource{Group: group, Resource: plural},
+		Kind:          kind,
+		Singular:      singular,
+		ShortNames:    shortNames,
+		Categories:    categories,
+		Scope:         scope,
+	})
+}
+
+// addBuiltinResources adds the kinds client-go knows about, must-gathers contain the CRDs but not the discovery of
+// the built-in resources.  Neither their scope nor their short names are known.
+func (r *ResourceResolver) addBuiltinResources() {
+	objectMetaType := reflect.TypeOf(metav1.ObjectMeta{})
+	gvks := []schema.GroupVersionKind{}
+	for gvk, t := range scheme.Scheme.AllKnownTypes() {
+		if gvk.Version == runtime.APIVersionInternal {
+			continue
+		}
+		// lists, options and watch events have no object metadata
+		if field, ok := t.FieldByName("ObjectMeta"); !ok || field.Type != objectMetaType {
+			continue
+		}
+		gvks = append(gvks, gvk)
+	}
+	// stable order, the first version of a group wins
+	sort.Slice(gvks, func(i, j int) bool {
+		return gvks[i].String() < gvks[j].String()
+	})
+	for _, gvk := range gvks {
+		plural, singular := meta.UnsafeGuessKindToResource(gvk)
+		r.add(&DiscoveredResource{
+			GroupResource: plural.GroupResource(),
+			Kind:          gvk.Kind,
+			Singular:      singular.Resource,
+		})
+	}
+}
+
+// LoadDiscoverySnapshot reads the discovery from files.  It understands APIResourceLists, eg. the serverresources.json
+// files of the kubectl discovery cache or `kubectl get --raw /apis/<group>/<version>`, and CustomResourceDefinitions,
+// eg. the cluster-scoped-resources/apiextensions.k8s.io directory of a must-gather.  Resources that are not in the
+// snapshot fall back to the built-in kinds.
+func LoadDiscoverySnapshot(path string) (*ResourceResolver, error) {
+	resolver := newResourceResolver()
+	err := filepath.Walk(path, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(filename) {
+		case ".json", ".yaml", ".yml":
+		default:
+			return nil
+		}
+		file, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if err := resolver.addSnapshotFile(file); err != nil {
+			klog.V(1).Infof("skipping %q: %v", filename, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resolver.resources) == 0 {
+		return nil, fmt.Errorf("no APIResourceList or CustomResourceDefinition found in %q", path)
+	}
+	resolver.addBuiltinResources()
+	return resolver, nil
+}
+
+func (r *ResourceResolver) addSnapshotFile(reader io.Reader) error {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(reader, 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := r.addSnapshotObject(obj); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *ResourceResolver) addSnapshotObject(obj *unstructured.Unstructured) error {
+	switch {
+	case obj.GetKind() == "APIResourceList":
+		list := &metav1.APIResourceList{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, list); err != nil {
+			return err
+		}
+		return r.addAPIResourceList(list)
+	case obj.GetKind() == "CustomResourceDefinition":
+		r.addCustomResourceDefinition(obj)
+	case obj.IsList():
+		return obj.EachListItem(func(item runtime.Object) error {
+			return r.addSnapshotObject(item.(*unstructured.Unstructured))
+		})
+	}
+	return nil
+}
+
+// DiscoverResources reads the discovery of a live cluster.  Groups that fail discovery are skipped.
+func DiscoverResources(restClientGetter genericclioptions.RESTClientGetter) (*ResourceResolver, error) {
+	config, err := restClientGetter.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	_, lists, err := client.ServerGroupsAndResources()
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, err
+		}
+		klog.Warningf("incomplete discovery: %v", err)
+	}
+
+	resolver := newResourceResolver()
+	for _, list := range lists {
+		if err := resolver.addAPIResourceList(list); err != nil {
+			return nil, err
+		}
+	}
+	return resolver, nil
+}
+
+// Get returns what discovery knows about a resource.
+func (r *ResourceResolver) Get(gr schema.GroupResource) (*DiscoveredResource, bool) {
+	if r == nil {
+		return nil, false
+	}
+	resource, ok := r.resources[gr]
+	return resource, ok
+}
+
+// ResolveResource finds the resources for a --resource value.  The value is a resource, a singular name, a short name,
+// a kind or a category, optionally followed by a group, eg. deploy, Deployment.apps or all.  A value without a group
+// matches every group, unless the core group has a match.
+func (r *ResourceResolver) ResolveResource(value string) []schema.GroupResource {
+	name, group, hasGroup := splitNameAndGroup(value)
+
+	ret := []schema.GroupResource{}
+	for gr, resource := range r.resources {
+		if hasGroup && gr.Group != group {
+			continue
+		}
+		if gr.Resource == name || resource.Singular == name || strings.ToLower(resource.Kind) == name || sets.NewString(resource.ShortNames...).Has(name) {
+			ret = append(ret, gr)
+		}
+	}
+	if !hasGroup {
+		// like in the request paths, no group means the core group when there is a core resource of that name
+		for _, gr := range ret {
+			if len(gr.Group) == 0 {
+				return []schema.GroupResource{gr}
+			}
+		}
+	}
+	if len(ret) == 0 {
+		for gr, resource := range r.resources {
+			if hasGroup && gr.Group != group {
+				continue
+			}
+			if sets.NewString(resource.Categories...).Has(name) {
+				ret = append(ret, gr)
+			}
+		}
+	}
+	sortGroupResources(ret)
+	return ret
+}
+
+// ResolveKind finds the resources of a kind, optionally followed by a group, eg. Deployment or Deployment.apps.
+func (r *ResourceResolver) ResolveKind(value string) []schema.GroupResource {
+	kind, group, hasGroup := splitNameAndGroup(value)
+
+	ret := []schema.GroupResource{}
+	for gr, resource := range r.resources {
+		if hasGroup && gr.Group != group {
+			continue
+		}
+		if strings.ToLower(resource.Kind) == kind {
+			ret = append(ret, gr)
+		}
+	}
+	sortGroupResources(ret)
+	return ret
+}
+
+// KindFor returns the group kind of a resource, the resource itself is returned when it is not known.
+func (r *ResourceResolver) KindFor(gr schema.GroupResource) string {
+	resource, ok := r.resources[gr]
+	if !ok {
+		return gr.String()
+	}
+	return schema.GroupKind{Group: gr.Group, Kind: resource.Kind}.String()
+}
+
+// splitNameAndGroup splits <name>.<group>, the core group is written as <name>. or <name>.core.
+func splitNameAndGroup(value string) (string, string, bool) {
+	parts := strings.SplitN(strings.ToLower(value), ".", 2)
+	if len(parts) == 1 {
+		return parts[0], "", false
+	}
+	if parts[1] == "core" {
+		return parts[0], "", true
+	}
+	return parts[0], parts[1], true
+}
+
+func sortGroupResources(grs []schema.GroupResource) {
+	sort.Slice(grs, func(i, j int) bool {
+		return grs[i].String() < grs[j].String()
+	})
+}
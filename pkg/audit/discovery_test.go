@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const testDiscoveryAPIResourceLists = `
+{"kind":"APIResourceList","apiVersion":"v1","groupVersion":"v1","resources":[
+  {"name":"pods","singularName":"pod","namespaced":true,"kind":"Pod","verbs":["get"],"shortNames":["po"],"categories":["all"]},
+  {"name":"pods/log","singularName":"","namespaced":true,"kind":"Pod","verbs":["get"]}]}
+{"kind":"APIResourceList","apiVersion":"v1","groupVersion":"metrics.k8s.io/v1beta1","resources":[
+  {"name":"pods","singularName":"","namespaced":true,"kind":"PodMetrics","verbs":["get"]}]}
+`
+
+const testDiscoveryCRDs = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  group: machine.openshift.io
+  scope: Namespaced
+  names: {plural: machines, singular: machine, kind: Machine, shortNames: [ma], categories: [all]}
+`
+
+func TestResourceResolver(t *testing.T) {
+	resolver := newResourceResolver()
+	for _, snapshot := range []string{testDiscoveryAPIResourceLists, testDiscoveryCRDs} {
+		if err := resolver.addSnapshotFile(strings.NewReader(snapshot)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resolver.addBuiltinResources()
+
+	pods := schema.GroupResource{Resource: "pods"}
+	machines := schema.GroupResource{Group: "machine.openshift.io", Resource: "machines"}
+	tests := []struct {
+		value    string
+		kind     bool
+		expected []schema.GroupResource
+	}{
+		{value: "pods", expected: []schema.GroupResource{pods}},
+		{value: "pods.metrics.k8s.io", expected: []schema.GroupResource{{Group: "metrics.k8s.io", Resource: "pods"}}},
+		{value: "po", expected: []schema.GroupResource{pods}},
+		{value: "ma", expected: []schema.GroupResource{machines}},
+		{value: "Machine", expected: []schema.GroupResource{machines}},
+		{value: "all", expected: []schema.GroupResource{machines, pods}},
+		{value: "pods/log", expected: []schema.GroupResource{}},
+		{value: "Deployment.apps", kind: true, expected: []schema.GroupResource{{Group: "apps", Resource: "deployments"}}},
+		{value: "Pod", kind: true, expected: []schema.GroupResource{pods}},
+		{value: "PodMetrics", kind: true, expected: []schema.GroupResource{{Group: "metrics.k8s.io", Resource: "pods"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			var actual []schema.GroupResource
+			if test.kind {
+				actual = resolver.ResolveKind(test.value)
+			} else {
+				actual = resolver.ResolveResource(test.value)
+			}
+			if !reflect.DeepEqual(test.expected, actual) {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+
+	if scope := resolver.resources[machines].Scope; scope != "namespace" {
+		t.Errorf("expected machines to be namespaced, got %q", scope)
+	}
+}
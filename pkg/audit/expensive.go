@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"sort"
+	"time"
+
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+const (
+	// unpaginatedListLatency is how slow an unpaginated list has to be to count as a list of a large collection.
+	unpaginatedListLatency = time.Second
+	// relistStormWindow and relistStormCount define a re-list storm: the same client listing the same thing this many
+	// times within the window, typically because its watches keep failing.
+	relistStormWindow = time.Minute
+	relistStormCount  = 10
+	// maxExpensiveExamples is the number of example requests kept per user.
+	maxExpensiveExamples = 3
+)
+
+// the reasons a list is expensive
+const (
+	ExpensiveClusterWideList  = "cluster-wide-no-selector"
+	ExpensiveWatchCacheBypass = "bypasses-watch-cache"
+	ExpensiveUnpaginatedList  = "unpaginated-large-list"
+	ExpensiveRelistStorm      = "relist-storm"
+)
+
+var ExpensiveReasons = []string{ExpensiveClusterWideList, ExpensiveWatchCacheBypass, ExpensiveUnpaginatedList, ExpensiveRelistStorm}
+
+// ExpensiveRequests are the expensive lists of one user.
+type ExpensiveRequests struct {
+	User  string
+	Count int
+	// TotalLatency is the cost the users are ranked by, the count times the average latency.
+	TotalLatency  time.Duration
+	ReasonToCount map[string]int
+	// Examples are the slowest expensive requests.
+	Examples []*auditv1.Event
+}
+
+func (e *ExpensiveRequests) addExample(event *auditv1.Event) {
+	e.Examples = append(e.Examples, event)
+	sort.Slice(e.Examples, func(i, j int) bool {
+		return Latency(e.Examples[i]) > Latency(e.Examples[j])
+	})
+	if len(e.Examples) > maxExpensiveExamples {
+		e.Examples = e.Examples[:maxExpensiveExamples]
+	}
+}
+
+// Latency is the time between the request being received and the stage of the event.
+func Latency(event *auditv1.Event) time.Duration {
+	return event.StageTimestamp.Time.Sub(event.RequestReceivedTimestamp.Time)
+}
+
+// expensiveListReasons returns why a single list is expensive for the apiserver.
+func expensiveListReasons(event *auditv1.Event) []string {
+	ns, _, _, _ := URIToParts(event.RequestURI)
+	params := queryParams(event.RequestURI)
+
+	reasons := []string{}
+	if len(ns) == 0 && len(params.Get("labelSelector")) == 0 && len(params.Get("fieldSelector")) == 0 {
+		reasons = append(reasons, ExpensiveClusterWideList)
+	}
+	// resourceVersion="" is a quorum read from etcd, the following pages of a list always are
+	if len(params.Get("resourceVersion")) == 0 && len(params.Get("continue")) == 0 {
+		reasons = append(reasons, ExpensiveWatchCacheBypass)
+	}
+	if len(params.Get("limit")) == 0 && Latency(event) >= unpaginatedListLatency {
+		reasons = append(reasons, ExpensiveUnpaginatedList)
+	}
+	return reasons
+}
+
+// findRelistStorms returns the lists that are part of a re-list storm, the events are sorted by time.  Only the first
+// pages count, the following pages of one paginated list are not re-lists.
+func findRelistStorms(lists []*auditv1.Event) map[*auditv1.Event]bool {
+	clientToLists := map[string][]*auditv1.Event{}
+	for _, event := range lists {
+		if len(queryParams(event.RequestURI).Get("continue")) > 0 {
+			continue
+		}
+		key := event.User.Username + "|" + event.UserAgent + "|" + auditURIKey(event.RequestURI)
+		clientToLists[key] = append(clientToLists[key], event)
+	}
+
+	ret := map[*auditv1.Event]bool{}
+	for _, clientLists := range clientToLists {
+		start := 0
+		for end := range clientLists {
+			for clientLists[end].RequestReceivedTimestamp.Sub(clientLists[start].RequestReceivedTimestamp.Time) > relistStormWindow {
+				start++
+			}
+			if end-start+1 >= relistStormCount {
+				for i := start; i <= end; i++ {
+					ret[clientLists[i]] = true
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// FindExpensiveRequests finds the lists that are costly for the apiserver: cluster-wide lists without selectors, lists
+// bypassing the watch cache, slow unpaginated lists and re-list storms.  Users are ranked by the total latency of their
+// expensive lists.
+func FindExpensiveRequests(events []*auditv1.Event) []*ExpensiveRequests {
+	lists := []*auditv1.Event{}
+	for _, event := range events {
+		if event.Verb == "list" {
+			lists = append(lists, event)
+		}
+	}
+	sort.SliceStable(lists, func(i, j int) bool {
+		return lists[i].RequestReceivedTimestamp.Time.Before(lists[j].RequestReceivedTimestamp.Time)
+	})
+	storms := findRelistStorms(lists)
+
+	users := map[string]*ExpensiveRequests{}
+	for _, event := range lists {
+		reasons := expensiveListReasons(event)
+		if storms[event] {
+			reasons = append(reasons, ExpensiveRelistStorm)
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+
+		user, ok := users[event.User.Username]
+		if !ok {
+			user = &ExpensiveRequests{User: event.User.Username, ReasonToCount: map[string]int{}}
+			users[event.User.Username] = user
+		}
+		user.Count++
+		user.TotalLatency += Latency(event)
+		for _, reason := range reasons {
+			user.ReasonToCount[reason]++
+		}
+		user.addExample(event)
+	}
+
+	ret := []*ExpensiveRequests{}
+	for _, user := range users {
+		ret = append(ret, user)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].TotalLatency != ret[j].TotalLatency {
+			return ret[i].TotalLatency > ret[j].TotalLatency
+		}
+		return ret[i].User < ret[j].User
+	})
+	return ret
+}
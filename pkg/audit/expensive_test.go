@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestFindRelistStorms(t *testing.T) {
+	start := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	newList := func(user, uri string, offset time.Duration) *auditv1.Event {
+		received := metav1.NewMicroTime(start.Add(offset))
+		return &auditv1.Event{
+			Verb:                     "list",
+			RequestURI:               uri,
+			User:                     authnv1.UserInfo{Username: user},
+			UserAgent:                user + "/v1",
+			RequestReceivedTimestamp: received,
+			StageTimestamp:           received,
+		}
+	}
+
+	events := []*auditv1.Event{}
+	// one list of 10k pods at limit=500 is 20 pages within a minute
+	events = append(events, newList("paginated", "/api/v1/pods?limit=500&resourceVersion=0", 0))
+	for i := 1; i < 20; i++ {
+		events = append(events, newList("paginated", fmt.Sprintf("/api/v1/pods?limit=500&continue=token-%d", i), time.Duration(i)*time.Second))
+	}
+	// a client re-listing from scratch every few seconds
+	for i := 0; i < relistStormCount; i++ {
+		events = append(events, newList("storm", "/api/v1/namespaces/foo/configmaps?limit=500&resourceVersion=0", time.Duration(i)*5*time.Second))
+	}
+
+	storms := findRelistStorms(events)
+	for _, event := range events {
+		expected := event.User.Username == "storm"
+		if storms[event] != expected {
+			t.Errorf("expected %s %q to be a re-list storm: %v", event.User.Username, event.RequestURI, expected)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+// maxFailureClusterExamples is the number of audit IDs kept per cluster.
+const maxFailureClusterExamples = 3
+
+var (
+	failureUIDRegex    = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	failureNumberRegex = regexp.MustCompile(`\b[0-9]+(\.[0-9]+)*\b`)
+	// failureNameRegex finds the quoted values that follow a resource, eg. `configmaps "foo"` or `deployments.apps "foo"`
+	failureNameRegex = regexp.MustCompile(`\b([a-z][a-z0-9]*(\.[a-z0-9-]+)*) "[^"]*"`)
+)
+
+// failureIdentifierWords are followed by the quoted resources, API groups and webhooks the failures are about, those are
+// kept.
+var failureIdentifierWords = map[string]bool{
+	"resource":    true,
+	"subresource": true,
+	"group":       true,
+	"webhook":     true,
+}
+
+// FailureCluster groups the failed requests with the same status code, reason and message template.
+type FailureCluster struct {
+	Code   int32
+	Reason string
+	// Template is the status message with the object names, namespaces, UIDs and numbers masked.
+	Template        string
+	Count           int
+	ExampleAuditIDs []types.UID
+	Users           sets.String
+	Resources       sets.String
+	First           time.Time
+	Last            time.Time
+}
+
+// templateFailureMessage masks the parts of a status message that differ between requests failing for the same
+// reason, eg. `configmaps "foo" already exists` becomes `configmaps "<name>" already exists`.  The quoted resources,
+// API groups and webhooks are kept, they tell failures apart.  UIDs are masked before the numbers they contain.
+func templateFailureMessage(message string) string {
+	message = failureUIDRegex.ReplaceAllString(message, "<uid>")
+	message = failureNameRegex.ReplaceAllStringFunc(message, func(match string) string {
+		word := failureNameRegex.FindStringSubmatch(match)[1]
+		switch {
+		case failureIdentifierWords[word]:
+			return match
+		case word == "namespace" || word == "namespaces":
+			return word + ` "<namespace>"`
+		}
+		return word + ` "<name>"`
+	})
+	return failureNumberRegex.ReplaceAllString(message, "<n>")
+}
+
+// ClusterFailures groups the events that failed with a status code of 400 or above.  Clusters are sorted by count.
+func ClusterFailures(events []*auditv1.Event) []*FailureCluster {
+	clusters := map[string]*FailureCluster{}
+	for _, event := range events {
+		if event.ResponseStatus == nil || event.ResponseStatus.Code < 400 {
+			continue
+		}
+		template := templateFailureMessage(event.ResponseStatus.Message)
+		reason := string(event.ResponseStatus.Reason)
+		key := fmt.Sprintf("%d/%s/%s", event.ResponseStatus.Code, reason, template)
+
+		cluster, ok := clusters[key]
+		if !ok {
+			cluster = &FailureCluster{
+				Code:      event.ResponseStatus.Code,
+				Reason:    reason,
+				Template:  template,
+				Users:     sets.NewString(),
+				Resources: sets.NewString(),
+				First:     event.RequestReceivedTimestamp.Time,
+			}
+			clusters[key] = cluster
+		}
+
+		cluster.Count++
+		if len(cluster.ExampleAuditIDs) < maxFailureClusterExamples {
+			cluster.ExampleAuditIDs = append(cluster.ExampleAuditIDs, event.AuditID)
+		}
+		cluster.Users.Insert(event.User.Username)
+		_, gvr, _, subresource := URIToParts(event.RequestURI)
+		resource := gvr.Resource
+		if len(gvr.Group) > 0 {
+			resource = gvr.Resource + "." + gvr.Group
+		}
+		if len(subresource) > 0 {
+			resource = resource + "/" + strings.SplitN(subresource, "/", 2)[0]
+		}
+		cluster.Resources.Insert(event.Verb + " " + resource)
+		if event.RequestReceivedTimestamp.Time.Before(cluster.First) {
+			cluster.First = event.RequestReceivedTimestamp.Time
+		}
+		if event.RequestReceivedTimestamp.Time.After(cluster.Last) {
+			cluster.Last = event.RequestReceivedTimestamp.Time
+		}
+	}
+
+	ret := []*FailureCluster{}
+	for _, cluster := range clusters {
+		ret = append(ret, cluster)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Count != ret[j].Count {
+			return ret[i].Count > ret[j].Count
+		}
+		if ret[i].Code != ret[j].Code {
+			return ret[i].Code < ret[j].Code
+		}
+		return ret[i].Template < ret[j].Template
+	})
+	return ret
+}
@@ -0,0 +1,79 @@
+package audit
+
+import "testing"
+
+func TestTemplateFailureMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected string
+	}{
+		{
+			name:     "quoted name",
+			message:  `configmaps "foo" already exists`,
+			expected: `configmaps "<name>" already exists`,
+		},
+		{
+			name:     "conflict with a uid and a quoted name",
+			message:  `Operation cannot be fulfilled on pods "bar": the object has been modified; please apply your changes to the latest version and try again, uid 1f2e3d4c-5b6a-4789-9abc-def012345678`,
+			expected: `Operation cannot be fulfilled on pods "<name>": the object has been modified; please apply your changes to the latest version and try again, uid <uid>`,
+		},
+		{
+			name:     "uid in a quoted name",
+			message:  `Precondition failed: UID in precondition: 1f2e3d4c-5b6a-4789-9abc-def012345678, UID in object meta: "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"`,
+			expected: `Precondition failed: UID in precondition: <uid>, UID in object meta: "<uid>"`,
+		},
+		{
+			name:     "grouped resource",
+			message:  `deployments.apps "foo" not found`,
+			expected: `deployments.apps "<name>" not found`,
+		},
+		{
+			name:     "namespace",
+			message:  `namespaces "foo" not found`,
+			expected: `namespaces "<namespace>" not found`,
+		},
+		{
+			name:     "forbidden keeps the resource and the group",
+			message:  `secrets "bar" is forbidden: User "alice" cannot get resource "secrets" in API group "" in the namespace "foo"`,
+			expected: `secrets "<name>" is forbidden: User "alice" cannot get resource "secrets" in API group "" in the namespace "<namespace>"`,
+		},
+		{
+			name:     "forbidden on another resource",
+			message:  `pods "bar" is forbidden: User "alice" cannot create resource "pods/exec" in API group "apps" in the namespace "foo"`,
+			expected: `pods "<name>" is forbidden: User "alice" cannot create resource "pods/exec" in API group "apps" in the namespace "<namespace>"`,
+		},
+		{
+			name:     "webhook",
+			message:  `admission webhook "a.example.com" denied the request: replicas must be odd`,
+			expected: `admission webhook "a.example.com" denied the request: replicas must be odd`,
+		},
+		{
+			name:     "webhook call failure",
+			message:  `Internal error occurred: failed calling webhook "b.example.com": failed to call webhook: Post "https://b.example.svc:443/validate?timeout=10s": context deadline exceeded`,
+			expected: `Internal error occurred: failed calling webhook "b.example.com": failed to call webhook: Post "https://b.example.svc:<n>/validate?timeout=10s": context deadline exceeded`,
+		},
+		{
+			name:     "numbers and versions",
+			message:  `Timeout: request did not complete within 60s, retry after 1.5 seconds`,
+			expected: `Timeout: request did not complete within 60s, retry after <n> seconds`,
+		},
+		{
+			name:     "numbers in words are kept",
+			message:  `etcdserver: request timed out, 3 of 3 members v3 apis failed`,
+			expected: `etcdserver: request timed out, <n> of <n> members v3 apis failed`,
+		},
+		{
+			name:     "no variable parts",
+			message:  `the server could not find the requested resource`,
+			expected: `the server could not find the requested resource`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := templateFailureMessage(test.message); actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
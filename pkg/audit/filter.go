@@ -2,8 +2,6 @@ package audit
 
 import (
 	"fmt"
-	"net/url"
-	"strings"
 	"time"
 
 	"github.com/openshift/cluster-debug-tools/pkg/util"
@@ -14,13 +12,15 @@ import (
 	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
 )
 
-type EventFilterPredicate interface {
+// Filter selects audit events.
+type Filter interface {
 	Matches(*auditv1.Event) bool
 }
 
-type AuditFilters []EventFilterPredicate
+// Filters keeps the events that match every filter.
+type Filters []Filter
 
-func (f AuditFilters) FilterEvents(events ...*auditv1.Event) []*auditv1.Event {
+func (f Filters) FilterEvents(events ...*auditv1.Event) []*auditv1.Event {
 	ret := make([]*auditv1.Event, len(events))
 	copy(ret, events)
 
@@ -31,7 +31,17 @@ func (f AuditFilters) FilterEvents(events ...*auditv1.Event) []*auditv1.Event {
 	return ret
 }
 
-func filterEvents(predicate EventFilterPredicate, events ...*auditv1.Event) []*auditv1.Event {
+// Matches is true when the event passes every filter.
+func (f Filters) Matches(event *auditv1.Event) bool {
+	for _, filter := range f {
+		if !filter.Matches(event) {
+			return false
+		}
+	}
+	return true
+}
+
+func filterEvents(predicate Filter, events ...*auditv1.Event) []*auditv1.Event {
 	ret := []*auditv1.Event{}
 	for i := range events {
 		event := events[i]
@@ -70,10 +80,12 @@ func (f *FilterByHTTPStatus) Matches(event *auditv1.Event) bool {
 
 type FilterByNamespaces struct {
 	Namespaces sets.String
+	// Resolver splits the request URIs with the scopes of discovery, it is optional.
+	Resolver *ResourceResolver
 }
 
 func (f *FilterByNamespaces) Matches(event *auditv1.Event) bool {
-	ns, _, _, _ := URIToParts(event.RequestURI)
+	ns, _, _, _ := f.Resolver.URIToParts(event.RequestURI)
 
 	return util.AcceptString(f.Namespaces, ns)
 
@@ -81,10 +93,12 @@ func (f *FilterByNamespaces) Matches(event *auditv1.Event) bool {
 
 type FilterBySubresources struct {
 	Subresources sets.String
+	// Resolver splits the request URIs with the scopes of discovery, it is optional.
+	Resolver *ResourceResolver
 }
 
 func (f *FilterBySubresources) Matches(event *auditv1.Event) bool {
-	_, _, _, subresource := URIToParts(event.RequestURI)
+	_, _, _, subresource := f.Resolver.URIToParts(event.RequestURI)
 
 	if f.Subresources.Has("-*") && len(f.Subresources) == 1 && len(subresource) == 0 {
 		return true
@@ -95,10 +109,12 @@ func (f *FilterBySubresources) Matches(event *auditv1.Event) bool {
 
 type FilterByNames struct {
 	Names sets.String
+	// Resolver splits the request URIs with the scopes of discovery, it is optional.
+	Resolver *ResourceResolver
 }
 
 func (f *FilterByNames) Matches(event *auditv1.Event) bool {
-	_, _, name, _ := URIToParts(event.RequestURI)
+	_, _, name, _ := f.Resolver.URIToParts(event.RequestURI)
 
 	if util.AcceptString(f.Names, name) {
 		return true
@@ -134,8 +150,7 @@ type FilterByFieldManager struct {
 }
 
 func (f *FilterByFieldManager) Matches(event *auditv1.Event) bool {
-	queryParams := QueryParams(event.RequestURI)
-	return util.AcceptString(f.FieldManagers, queryParams.Get("fieldManager"))
+	return util.AcceptString(f.FieldManagers, queryParams(event.RequestURI).Get("fieldManager"))
 }
 
 type FilterByVerbs struct {
@@ -148,10 +163,12 @@ func (f *FilterByVerbs) Matches(event *auditv1.Event) bool {
 
 type FilterByResources struct {
 	Resources map[schema.GroupResource]bool
+	// Resolver splits the request URIs with the scopes of discovery, it is optional.
+	Resolver *ResourceResolver
 }
 
 func (f *FilterByResources) Matches(event *auditv1.Event) bool {
-	_, gvr, _, _ := URIToParts(event.RequestURI)
+	_, gvr, _, _ := f.Resolver.URIToParts(event.RequestURI)
 	antiMatch := schema.GroupResource{Resource: "-" + gvr.Resource, Group: gvr.Group}
 
 	// check for an anti-match
@@ -190,138 +207,12 @@ func (f *FilterByResources) Matches(event *auditv1.Event) bool {
 
 func getFieldManagerQualifiedUsername(event *auditv1.Event) string {
 	username := event.User.Username
-	if fieldManager := QueryParams(event.RequestURI).Get("fieldManager"); len(fieldManager) > 0 {
+	if fieldManager := queryParams(event.RequestURI).Get("fieldManager"); len(fieldManager) > 0 {
 		username = fmt.Sprintf("%s[%s]", username, fieldManager)
 	}
 	return username
 }
 
-func QueryParams(uri string) url.Values {
-	// some request URL has query parameters like: /apis/image.openshift.io/v1/images?limit=500&resourceVersion=0
-	// we are not interested in the query parameters.
-	parts := strings.Split(uri, "?")
-	if len(parts) < 2 {
-		return nil
-	}
-	queryParams := parts[1]
-	values, err := url.ParseQuery(queryParams)
-	if err != nil {
-		panic(err)
-	}
-	return values
-}
-
-func URIToParts(uri string) (string, schema.GroupVersionResource, string, string) {
-	ns := ""
-	gvr := schema.GroupVersionResource{}
-	name := ""
-
-	if len(uri) >= 1 {
-		if uri[0] == '/' {
-			uri = uri[1:]
-		}
-	}
-
-	// some request URL has query parameters like: /apis/image.openshift.io/v1/images?limit=500&resourceVersion=0
-	// we are not interested in the query parameters.
-	uri = strings.Split(uri, "?")[0]
-	parts := strings.Split(uri, "/")
-	if len(parts) == 0 {
-		return ns, gvr, name, ""
-	}
-	// /api/v1/namespaces/<name>
-	if parts[0] == "api" {
-		if len(parts) >= 2 {
-			gvr.Version = parts[1]
-		}
-		if len(parts) < 3 {
-			return ns, gvr, name, ""
-		}
-
-		switch {
-		case parts[2] != "namespaces": // cluster scoped request that is not a namespace
-			gvr.Resource = parts[2]
-			if len(parts) >= 4 {
-				name = parts[3]
-				return ns, gvr, name, ""
-			}
-		case len(parts) == 3 && parts[2] == "namespaces": // a namespace request /api/v1/namespaces
-			gvr.Resource = parts[2]
-			return "", gvr, "", ""
-
-		case len(parts) == 4 && parts[2] == "namespaces": // a namespace request /api/v1/namespaces/<name>
-			gvr.Resource = parts[2]
-			name = parts[3]
-			ns = parts[3]
-			return ns, gvr, name, ""
-
-		case len(parts) == 5 && parts[2] == "namespaces" && parts[4] == "finalize", // a namespace request /api/v1/namespaces/<name>/finalize
-			len(parts) == 5 && parts[2] == "namespaces" && parts[4] == "status": // a namespace request /api/v1/namespaces/<name>/status
-			gvr.Resource = parts[2]
-			name = parts[3]
-			ns = parts[3]
-			return ns, gvr, name, parts[4]
-
-		default:
-			// this is not a cluster scoped request and not a namespace request we recognize
-		}
-
-		if len(parts) < 4 {
-			return ns, gvr, name, ""
-		}
-
-		ns = parts[3]
-		if len(parts) >= 5 {
-			gvr.Resource = parts[4]
-		}
-		if len(parts) >= 6 {
-			name = parts[5]
-		}
-		if len(parts) >= 7 {
-			return ns, gvr, name, strings.Join(parts[6:], "/")
-		}
-		return ns, gvr, name, ""
-	}
-
-	if parts[0] != "apis" {
-		return ns, gvr, name, ""
-	}
-
-	// /apis/group/v1/namespaces/<name>
-	if len(parts) >= 2 {
-		gvr.Group = parts[1]
-	}
-	if len(parts) >= 3 {
-		gvr.Version = parts[2]
-	}
-	if len(parts) < 4 {
-		return ns, gvr, name, ""
-	}
-
-	if parts[3] != "namespaces" {
-		gvr.Resource = parts[3]
-		if len(parts) >= 5 {
-			name = parts[4]
-			return ns, gvr, name, ""
-		}
-	}
-	if len(parts) < 5 {
-		return ns, gvr, name, ""
-	}
-
-	ns = parts[4]
-	if len(parts) >= 6 {
-		gvr.Resource = parts[5]
-	}
-	if len(parts) >= 7 {
-		name = parts[6]
-	}
-	if len(parts) >= 8 {
-		return ns, gvr, name, strings.Join(parts[7:], "/")
-	}
-	return ns, gvr, name, ""
-}
-
 type FilterByStage struct {
 	Stages sets.String
 }
@@ -359,6 +250,33 @@ func (f *FilterByDuration) Matches(event *auditv1.Event) bool {
 	return event.StageTimestamp.Sub(event.RequestReceivedTimestamp.Time) <= f.Duration
 }
 
+type FilterByDryRun struct {
+	DryRun bool
+}
+
+func (f *FilterByDryRun) Matches(event *auditv1.Event) bool {
+	return IsDryRun(event) == f.DryRun
+}
+
+type FilterByPatchTypes struct {
+	PatchTypes sets.String
+}
+
+func (f *FilterByPatchTypes) Matches(event *auditv1.Event) bool {
+	patchType := PatchType(event)
+	if len(patchType) == 0 {
+		return false
+	}
+	return util.AcceptString(f.PatchTypes, patchType)
+}
+
+type FilterByApplyConflicts struct {
+}
+
+func (f *FilterByApplyConflicts) Matches(event *auditv1.Event) bool {
+	return IsApplyConflict(event)
+}
+
 type FilterByAnnotationPresence struct {
 	AnnotationKey string
 }
@@ -373,8 +291,8 @@ const (
 	PodSecurityViolationsPod = "pod"
 )
 
-func NewFilterByPodSecurityViolations(filterType string) EventFilterPredicate {
-	filters := []EventFilterPredicate{}
+func NewFilterByPodSecurityViolations(filterType string) Filter {
+	filters := []Filter{}
 	if filterType == PodSecurityViolationsPod {
 		filters = append(filters,
 			&FilterByResources{
@@ -388,7 +306,7 @@ func NewFilterByPodSecurityViolations(filterType string) EventFilterPredicate {
 }
 
 type FilterUnion struct {
-	filters []EventFilterPredicate
+	filters []Filter
 }
 
 func (f *FilterUnion) Matches(event *auditv1.Event) bool {
@@ -0,0 +1,171 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// FilterBuilder describes a query and builds its filters, the zero value matches every event.  The values follow the
+// syntax of the audit command: a leading "-" excludes a value, a trailing "*" is a wildcard and resources and kinds are
+// written resource.group or Kind.group.
+type FilterBuilder struct {
+	UIDs          []string
+	Verbs         []string
+	Resources     []string
+	Kinds         []string
+	Subresources  []string
+	Namespaces    []string
+	Names         []string
+	Users         []string
+	FieldManagers []string
+	Stages        []string
+
+	HTTPStatusCodes []int32
+	FailedOnly      bool
+
+	// DryRun is DryRunOnly to only keep the dry-run requests or DryRunExclude to drop them.
+	DryRun string
+	// PatchTypes only keeps the patches of these types, see PatchTypes.
+	PatchTypes []string
+	// ApplyConflictsOnly only keeps the server-side apply patches that failed on conflicts.
+	ApplyConflictsOnly bool
+
+	// After and Before bound the time the requests were received, a zero time leaves that side open.
+	After  time.Time
+	Before time.Time
+	// MaxDuration only keeps the requests that completed within it, zero keeps every request.
+	MaxDuration time.Duration
+
+	// PodSecurityViolations is PodSecurityViolationsPod or PodSecurityViolationsAll to only keep the requests that
+	// violated the pod security policy of their namespace.
+	PodSecurityViolations string
+
+	// Resolver resolves kinds, short names and categories of Resources and splits the request URIs with the scopes of
+	// discovery.  Kinds require it.
+	Resolver *ResourceResolver
+}
+
+// Build returns the filters of the query.
+func (b *FilterBuilder) Build() (Filters, error) {
+	filters := Filters{}
+	if len(b.UIDs) > 0 {
+		filters = append(filters, &FilterByUIDs{UIDs: sets.NewString(b.UIDs...)})
+	}
+	if len(b.Names) > 0 {
+		filters = append(filters, &FilterByNames{Names: sets.NewString(b.Names...), Resolver: b.Resolver})
+	}
+	if len(b.Namespaces) > 0 {
+		filters = append(filters, &FilterByNamespaces{Namespaces: sets.NewString(b.Namespaces...), Resolver: b.Resolver})
+	}
+	if len(b.Stages) > 0 {
+		filters = append(filters, &FilterByStage{Stages: sets.NewString(b.Stages...)})
+	}
+	if !b.Before.IsZero() {
+		filters = append(filters, &FilterByBefore{Before: b.Before})
+	}
+	if !b.After.IsZero() {
+		filters = append(filters, &FilterByAfter{After: b.After})
+	}
+	if len(b.Resources) > 0 {
+		resources := map[schema.GroupResource]bool{}
+		for _, resource := range b.Resources {
+			if resolved := resolveResourceFilter(b.Resolver, resource); len(resolved) > 0 {
+				for _, gr := range resolved {
+					resources[gr] = true
+				}
+				continue
+			}
+			parts := strings.Split(resource, ".")
+			gr := schema.GroupResource{}
+			gr.Resource = parts[0]
+			if len(parts) >= 2 {
+				gr.Group = strings.Join(parts[1:], ".")
+			}
+			resources[gr] = true
+		}
+
+		filters = append(filters, &FilterByResources{Resources: resources, Resolver: b.Resolver})
+	}
+	if len(b.Kinds) > 0 {
+		if b.Resolver == nil {
+			return nil, fmt.Errorf("filtering by kind requires a discovery")
+		}
+		resources := map[schema.GroupResource]bool{}
+		for _, kind := range b.Kinds {
+			resolved := b.Resolver.ResolveKind(kind)
+			if len(resolved) == 0 {
+				return nil, fmt.Errorf("kind %q is not in the discovery", kind)
+			}
+			for _, gr := range resolved {
+				resources[gr] = true
+			}
+		}
+		filters = append(filters, &FilterByResources{Resources: resources, Resolver: b.Resolver})
+	}
+	if len(b.Subresources) > 0 {
+		filters = append(filters, &FilterBySubresources{Subresources: sets.NewString(b.Subresources...), Resolver: b.Resolver})
+	}
+	if len(b.Users) > 0 {
+		filters = append(filters, &FilterByUser{Users: sets.NewString(b.Users...)})
+	}
+	if len(b.FieldManagers) > 0 {
+		filters = append(filters, &FilterByFieldManager{FieldManagers: sets.NewString(b.FieldManagers...)})
+	}
+	if len(b.Verbs) > 0 {
+		filters = append(filters, &FilterByVerbs{Verbs: sets.NewString(b.Verbs...)})
+	}
+	if len(b.HTTPStatusCodes) > 0 {
+		filters = append(filters, &FilterByHTTPStatus{HTTPStatusCodes: sets.NewInt32(b.HTTPStatusCodes...)})
+	}
+	if b.FailedOnly {
+		filters = append(filters, &FilterByFailures{})
+	}
+	switch b.DryRun {
+	case "":
+	case DryRunOnly:
+		filters = append(filters, &FilterByDryRun{DryRun: true})
+	case DryRunExclude:
+		filters = append(filters, &FilterByDryRun{DryRun: false})
+	default:
+		return nil, fmt.Errorf("unsupported dry run filter %q, available values are: [only,exclude]", b.DryRun)
+	}
+	if len(b.PatchTypes) > 0 {
+		filters = append(filters, &FilterByPatchTypes{PatchTypes: sets.NewString(b.PatchTypes...)})
+	}
+	if b.ApplyConflictsOnly {
+		filters = append(filters, &FilterByApplyConflicts{})
+	}
+	if b.MaxDuration > 0 {
+		filters = append(filters, &FilterByDuration{b.MaxDuration})
+	}
+
+	switch b.PodSecurityViolations {
+	case "":
+	case PodSecurityViolationsAll, PodSecurityViolationsPod:
+		filters = append(filters, NewFilterByPodSecurityViolations(b.PodSecurityViolations))
+	default:
+		return nil, fmt.Errorf("unsupported pod security violations filter %q, available values are: [pod,all]", b.PodSecurityViolations)
+	}
+
+	return filters, nil
+}
+
+// resolveResourceFilter resolves a resource value through discovery.  Values with wildcards and values discovery does
+// not know are left to the path matching.
+func resolveResourceFilter(resolver *ResourceResolver, value string) []schema.GroupResource {
+	if resolver == nil || strings.Contains(value, "*") {
+		return nil
+	}
+	exclude := strings.HasPrefix(value, "-")
+	resolved := resolver.ResolveResource(strings.TrimPrefix(value, "-"))
+	if exclude {
+		for i := range resolved {
+			resolved[i].Resource = "-" + resolved[i].Resource
+		}
+	}
+	return resolved
+}
@@ -0,0 +1,170 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	"k8s.io/klog"
+)
+
+// followPollInterval is how often a followed audit log is checked for new lines and rotation.
+const followPollInterval = 250 * time.Millisecond
+
+// auditFileFollower reads the lines appended to an audit log.  Like `tail -F`, it reopens the path when kube-apiserver
+// rotates the log to audit-<timestamp>.log and starts over when the log is truncated.
+type auditFileFollower struct {
+	path         string
+	pollInterval time.Duration
+
+	file   *os.File
+	reader *bufio.Reader
+	// pending is the start of a line that is still being written.
+	pending []byte
+}
+
+func newAuditFileFollower(path string, pollInterval time.Duration) *auditFileFollower {
+	return &auditFileFollower{path: path, pollInterval: pollInterval}
+}
+
+func (f *auditFileFollower) open(whence int) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, whence); err != nil {
+		file.Close()
+		return err
+	}
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file = file
+	f.reader = bufio.NewReaderSize(file, 64*1024)
+	f.pending = nil
+	return nil
+}
+
+// readLines hands every complete line up to the end of the current file to handle.
+func (f *auditFileFollower) readLines(handle func(line []byte) error) error {
+	for {
+		line, err := f.reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			f.pending = append(f.pending, line...)
+			if len(f.pending) > maxAuditLineSize {
+				klog.V(1).Infof("skipping a line longer than %d bytes in %q", maxAuditLineSize, f.path)
+				f.pending = nil
+			}
+			continue
+		}
+		if err == io.EOF {
+			f.pending = append(f.pending, line...)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(f.pending) > 0 {
+			line = append(f.pending, line...)
+			f.pending = nil
+		}
+		if err := handle(bytes.TrimRight(line, "\r\n")); err != nil {
+			return err
+		}
+	}
+}
+
+// rotation reports whether the path is now a different file and whether the current file was truncated.
+func (f *auditFileFollower) rotation() (bool, bool, error) {
+	current, err := f.file.Stat()
+	if err != nil {
+		return false, false, err
+	}
+	info, err := os.Stat(f.path)
+	if os.IsNotExist(err) {
+		// between the rename and the creation of the new log
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	if !os.SameFile(current, info) {
+		return true, false, nil
+	}
+	offset, err := f.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, false, err
+	}
+	return false, current.Size() < offset, nil
+}
+
+// Follow hands the lines appended to the audit log after it was opened to handle, until the context is done.
+func (f *auditFileFollower) Follow(ctx context.Context, handle func(line []byte) error) error {
+	if err := f.open(io.SeekEnd); err != nil {
+		return err
+	}
+	return f.follow(ctx, handle)
+}
+
+func (f *auditFileFollower) follow(ctx context.Context, handle func(line []byte) error) error {
+	defer func() {
+		// the file changes on every rotation
+		f.file.Close()
+	}()
+
+	for {
+		if err := f.readLines(handle); err != nil {
+			return err
+		}
+
+		rotated, truncated, err := f.rotation()
+		if err != nil {
+			return err
+		}
+		switch {
+		case rotated:
+			// the last lines may have been written between our last read and the rename
+			if err := f.readLines(handle); err != nil {
+				return err
+			}
+			klog.V(1).Infof("%q was rotated, reopening", f.path)
+			if err := f.open(io.SeekStart); err != nil {
+				return err
+			}
+			continue
+		case truncated:
+			klog.V(1).Infof("%q was truncated, reading from the start", f.path)
+			if err := f.open(io.SeekStart); err != nil {
+				return err
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(f.pollInterval):
+		}
+	}
+}
+
+// FollowEvents hands the events appended to the audit log that pass the filters to handle, until the context is
+// done.
+func FollowEvents(ctx context.Context, path string, filters Filters, handle func(*auditv1.Event) error) error {
+	return newAuditFileFollower(path, followPollInterval).Follow(ctx, func(line []byte) error {
+		_, event, err := parseAuditLine(line)
+		if err != nil {
+			klog.V(1).Infof("unable to decode a line of %q to audit event: %v\n", path, err)
+			return nil
+		}
+		if event == nil || !filters.Matches(event) {
+			return nil
+		}
+		return handle(event)
+	})
+}
@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAuditFileFollower(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	if err := os.WriteFile(path, []byte("before-follow\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock := sync.Mutex{}
+	lines := []string{}
+	waitForLines := func(n int) {
+		t.Helper()
+		for i := 0; i < 200; i++ {
+			lock.Lock()
+			current := len(lines)
+			lock.Unlock()
+			if current >= n {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %d lines", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	follower := newAuditFileFollower(path, 10*time.Millisecond)
+	// the follower starts at the end of the log
+	if err := follower.open(io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		done <- follower.follow(ctx, func(line []byte) error {
+			lock.Lock()
+			defer lock.Unlock()
+			lines = append(lines, string(line))
+			return nil
+		})
+	}()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a line written in two parts is handled once it is complete
+	file.WriteString("first")
+	time.Sleep(50 * time.Millisecond)
+	file.WriteString(" line\n")
+	waitForLines(1)
+
+	// rotate like kube-apiserver: the last line lands in the renamed log
+	file.WriteString("before-rotation\n")
+	file.Close()
+	if err := os.Rename(path, filepath.Join(dir, "audit-2026-10-18T10-00-00.000.log")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("after-rotation\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitForLines(3)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"first line", "before-rotation", "after-rotation"}
+	lock.Lock()
+	defer lock.Unlock()
+	if !reflect.DeepEqual(expected, lines) {
+		t.Errorf("expected %q, got %q", expected, lines)
+	}
+}
@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+const (
+	// leaderElectionRecordAnnotationKey is the annotation in which ConfigMap and Endpoints based locks store the leader.
+	leaderElectionRecordAnnotationKey = "control-plane.alpha.kubernetes.io/leader"
+	nodeLeaseNamespace                = "kube-node-lease"
+)
+
+// LeaseTimeline is the history of a single leader election lock or node lease.
+type LeaseTimeline struct {
+	Namespace string
+	Name      string
+	// Resource is either leases.coordination.k8s.io or configmaps.
+	Resource string
+	Renewals []LeaseRenewal
+	// Transitions are the indexes in Renewals where the holder changed.  Holders read from the request body are only
+	// compared with each other, and so are the holders assumed from the requesting user.
+	Transitions []int
+	// Releases are the indexes in Renewals where the holder gave the lock up by writing an empty holder.
+	Releases []int
+	// Gaps are the indexes in Renewals that came after a gap bigger than the threshold.
+	Gaps []int
+}
+
+// LeaseRenewal is a successful write to the lock.
+type LeaseRenewal struct {
+	Timestamp time.Time
+	Holder    string
+	Username  string
+	// HolderIsUsername is true when the request body was not recorded and the requesting user is assumed to be the holder.
+	HolderIsUsername bool
+	// Released is true for the writes of an empty holder, the holder is empty.
+	Released bool
+}
+
+// IsNodeLease is true for kubelet heartbeats.
+func (l *LeaseTimeline) IsNodeLease() bool {
+	return l.Namespace == nodeLeaseNamespace
+}
+
+// HolderIsUsername counts the renewals whose holder is the requesting user rather than the holder in the request body.
+// Leader transitions between the replicas of an operator sharing a service account are not seen in those.
+func (l *LeaseTimeline) HolderIsUsername() int {
+	count := 0
+	for _, renewal := range l.Renewals {
+		if renewal.HolderIsUsername {
+			count++
+		}
+	}
+	return count
+}
+
+// PreviousHolder returns the holder a transition at index i is from, the last holder known the same way before it.
+func (l *LeaseTimeline) PreviousHolder(i int) string {
+	for j := i - 1; j >= 0; j-- {
+		if !l.Renewals[j].Released && l.Renewals[j].HolderIsUsername == l.Renewals[i].HolderIsUsername {
+			return l.Renewals[j].Holder
+		}
+	}
+	return ""
+}
+
+// MaxGap returns the biggest time between two renewals.
+func (l *LeaseTimeline) MaxGap() time.Duration {
+	max := time.Duration(0)
+	for i := 1; i < len(l.Renewals); i++ {
+		if gap := l.Renewals[i].Timestamp.Sub(l.Renewals[i-1].Timestamp); gap > max {
+			max = gap
+		}
+	}
+	return max
+}
+
+// BuildLeaseTimelines collects the successful writes to leases and ConfigMap based locks.  The holder is read from the
+// request body when the event was recorded at Request level or above, otherwise the requesting user is assumed to be
+// the holder because only the holder renews a lock.  Renewals further apart than gapThreshold are flagged.
+func BuildLeaseTimelines(events []*auditv1.Event, gapThreshold time.Duration) []*LeaseTimeline {
+	timelines := map[string]*LeaseTimeline{}
+	for _, event := range events {
+		if !isWriteVerb(event.Verb) || event.Verb == "delete" || event.Verb == "deletecollection" {
+			continue
+		}
+		if event.ResponseStatus == nil || event.ResponseStatus.Code > 299 {
+			continue
+		}
+		ns, gvr, name, subresource := URIToParts(event.RequestURI)
+		if event.ObjectRef != nil && len(name) == 0 {
+			name = event.ObjectRef.Name
+		}
+		if len(name) == 0 || len(subresource) > 0 {
+			continue
+		}
+
+		var holder string
+		var fromBody bool
+		switch {
+		case gvr.Group == "coordination.k8s.io" && gvr.Resource == "leases":
+			holder, fromBody = leaseHolderFromBody(event)
+		case gvr.Group == "" && gvr.Resource == "configmaps":
+			holder, fromBody = configMapLockHolderFromBody(event)
+			if !fromBody && !looksLikeConfigMapLock(name) {
+				continue
+			}
+		default:
+			continue
+		}
+		// an empty holder in the body is a release, the leader steps down
+		released := fromBody && len(holder) == 0
+		holderIsUsername := !fromBody
+		if holderIsUsername {
+			holder = event.User.Username
+		}
+
+		key := gvr.Resource + "/" + ns + "/" + name
+		timeline, ok := timelines[key]
+		if !ok {
+			timeline = &LeaseTimeline{Namespace: ns, Name: name, Resource: gvr.GroupResource().String()}
+			timelines[key] = timeline
+		}
+		timeline.Renewals = append(timeline.Renewals, LeaseRenewal{
+			Timestamp:        event.RequestReceivedTimestamp.Time,
+			Holder:           holder,
+			Username:         event.User.Username,
+			HolderIsUsername: holderIsUsername,
+			Released:         released,
+		})
+	}
+
+	ret := []*LeaseTimeline{}
+	for _, timeline := range timelines {
+		sort.SliceStable(timeline.Renewals, func(i, j int) bool {
+			return timeline.Renewals[i].Timestamp.Before(timeline.Renewals[j].Timestamp)
+		})
+		// the last holders read from the bodies and assumed from the users, they are never compared with each other
+		lastHolders := map[bool]string{}
+		for i, renewal := range timeline.Renewals {
+			if i > 0 && renewal.Timestamp.Sub(timeline.Renewals[i-1].Timestamp) > gapThreshold {
+				timeline.Gaps = append(timeline.Gaps, i)
+			}
+			if renewal.Released {
+				timeline.Releases = append(timeline.Releases, i)
+				continue
+			}
+			if last, ok := lastHolders[renewal.HolderIsUsername]; ok && last != renewal.Holder {
+				timeline.Transitions = append(timeline.Transitions, i)
+			}
+			lastHolders[renewal.HolderIsUsername] = renewal.Holder
+		}
+		ret = append(ret, timeline)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Namespace != ret[j].Namespace {
+			return ret[i].Namespace < ret[j].Namespace
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// leaseHolderFromBody returns the holder of the lease and whether the request body was recorded, the holder is empty
+// when the lease was released.
+func leaseHolderFromBody(event *auditv1.Event) (string, bool) {
+	if event.RequestObject == nil || len(event.RequestObject.Raw) == 0 {
+		return "", false
+	}
+	lease := &coordinationv1.Lease{}
+	if err := json.Unmarshal(event.RequestObject.Raw, lease); err != nil {
+		return "", false
+	}
+	if lease.Spec.HolderIdentity == nil {
+		return "", true
+	}
+	return *lease.Spec.HolderIdentity, true
+}
+
+// configMapLockHolderFromBody returns the holder stored in the leader election annotation and whether it could be
+// read, the holder is empty when the lock was released.
+func configMapLockHolderFromBody(event *auditv1.Event) (string, bool) {
+	if event.RequestObject == nil || len(event.RequestObject.Raw) == 0 {
+		return "", false
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := json.Unmarshal(event.RequestObject.Raw, configMap); err != nil {
+		return "", false
+	}
+	record, ok := configMap.Annotations[leaderElectionRecordAnnotationKey]
+	if !ok {
+		return "", false
+	}
+	leaderElectionRecord := struct {
+		HolderIdentity string `json:"holderIdentity"`
+	}{}
+	if err := json.Unmarshal([]byte(record), &leaderElectionRecord); err != nil {
+		return "", false
+	}
+	return leaderElectionRecord.HolderIdentity, true
+}
+
+// looksLikeConfigMapLock catches the usual lock names when the audit level doesn't include the request body.
+func looksLikeConfigMapLock(name string) bool {
+	return strings.HasSuffix(name, "-lock") || strings.Contains(name, "leader")
+}
@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestBuildLeaseTimelines(t *testing.T) {
+	start := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	newEvent := func(uri, body string, offset time.Duration) *auditv1.Event {
+		event := &auditv1.Event{
+			Level:                    auditv1.LevelMetadata,
+			Verb:                     "update",
+			RequestURI:               uri,
+			User:                     authnv1.UserInfo{Username: "system:serviceaccount:openshift-foo:foo-operator"},
+			RequestReceivedTimestamp: metav1.NewMicroTime(start.Add(offset)),
+			ResponseStatus:           &metav1.Status{Code: 200},
+		}
+		if len(body) > 0 {
+			event.Level = auditv1.LevelRequestResponse
+			event.RequestObject = &runtime.Unknown{Raw: []byte(body)}
+		}
+		return event
+	}
+	lease := func(holder string) string {
+		return fmt.Sprintf(`{"kind":"Lease","apiVersion":"coordination.k8s.io/v1","spec":{"holderIdentity":%q}}`, holder)
+	}
+	configMapLock := func(holder string) string {
+		return fmt.Sprintf(`{"kind":"ConfigMap","apiVersion":"v1","metadata":{"annotations":{"control-plane.alpha.kubernetes.io/leader":%q}}}`,
+			fmt.Sprintf(`{"holderIdentity":%q}`, holder))
+	}
+
+	leaseURI := "/apis/coordination.k8s.io/v1/namespaces/openshift-foo/leases/foo-lock"
+	configMapURI := "/api/v1/namespaces/openshift-foo/configmaps/foo-leader"
+	events := []*auditv1.Event{
+		// the replicas of the operator share the service account, only the bodies tell them apart
+		newEvent(leaseURI, lease("foo-operator-1"), 0),
+		newEvent(leaseURI, lease("foo-operator-1"), 10*time.Second),
+		newEvent(leaseURI, lease("foo-operator-2"), 20*time.Second),
+		// the leader steps down, then the other replica takes over
+		newEvent(leaseURI, lease(""), 30*time.Second),
+		newEvent(leaseURI, lease("foo-operator-1"), 40*time.Second),
+		// mixed levels, the holder of the body is not compared with the user
+		newEvent(configMapURI, configMapLock("foo-operator-1"), 0),
+		newEvent(configMapURI, "", 10*time.Second),
+		newEvent(configMapURI, configMapLock("foo-operator-1"), 20*time.Second),
+		newEvent(configMapURI, "", 30*time.Second),
+		newEvent(configMapURI, configMapLock("foo-operator-2"), 40*time.Second),
+	}
+
+	timelines := BuildLeaseTimelines(events, time.Minute)
+	if len(timelines) != 2 {
+		t.Fatalf("expected two timelines, got %d", len(timelines))
+	}
+	for _, timeline := range timelines {
+		holders := []string{}
+		for _, renewal := range timeline.Renewals {
+			holders = append(holders, renewal.Holder)
+		}
+		switch timeline.Resource {
+		case "leases.coordination.k8s.io":
+			if !reflect.DeepEqual([]int{2, 4}, timeline.Transitions) || !reflect.DeepEqual([]int{3}, timeline.Releases) || timeline.HolderIsUsername() != 0 {
+				t.Errorf("expected transitions at 2 and 4 and a release at 3, got %v and %v with holders %v", timeline.Transitions, timeline.Releases, holders)
+			}
+			if previous := timeline.PreviousHolder(4); previous != "foo-operator-2" {
+				t.Errorf("expected the transition after the release to be from foo-operator-2, got %q", previous)
+			}
+		case "configmaps":
+			if !reflect.DeepEqual([]int{4}, timeline.Transitions) || len(timeline.Releases) != 0 || timeline.HolderIsUsername() != 2 {
+				t.Errorf("expected a single transition at 4 and two renewals without a holder, got %v with holders %v", timeline.Transitions, holders)
+			}
+			if holders[1] != "system:serviceaccount:openshift-foo:foo-operator" {
+				t.Errorf("expected the user to stand in for the holder, got %v", holders)
+			}
+		default:
+			t.Errorf("unexpected timeline of %s", timeline.Resource)
+		}
+	}
+}
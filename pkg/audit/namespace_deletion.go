@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+const namespaceControllerUser = "system:serviceaccount:kube-system:namespace-controller"
+
+// NamespaceDeletion is the reconstruction of a namespace deletion from the audit logs.
+type NamespaceDeletion struct {
+	Namespace string
+	// Delete is the last successful DELETE issued against the namespace, or the last DELETE when none succeeded.  Failed
+	// DELETEs and the earlier lifecycles of a reused namespace name would anchor the report at the wrong time.
+	Delete *auditv1.Event
+	// NamespaceUpdates are the finalize and status updates of the namespace that followed the DELETE.
+	NamespaceUpdates []*auditv1.Event
+	// CollectionDeletes are the deletecollection calls of the namespace controller, per resource.
+	CollectionDeletes []*ResourceActivity
+	// ObjectDeletes are the deletes of single objects by the namespace controller, per resource.  It falls back to them
+	// for the resources that do not support deletecollection.
+	ObjectDeletes []*ResourceActivity
+	// RemainingActivity are the writes to resources in the namespace that were not issued by the namespace controller
+	// after the DELETE, per resource and user.  Finalizer removals by other controllers show up here.
+	RemainingActivity []*ResourceActivity
+}
+
+// ResourceActivity counts requests against a resource in the namespace.
+type ResourceActivity struct {
+	Resource          string
+	User              string
+	Verbs             sets.String
+	Count             int
+	StatusCodeToCount map[int32]int
+	First             time.Time
+	Last              time.Time
+}
+
+func (a *ResourceActivity) addEvent(event *auditv1.Event) {
+	timestamp := event.RequestReceivedTimestamp.Time
+	if a.Count == 0 || timestamp.Before(a.First) {
+		a.First = timestamp
+	}
+	if timestamp.After(a.Last) {
+		a.Last = timestamp
+	}
+	a.Count++
+	a.Verbs.Insert(event.Verb)
+	code := int32(0)
+	if event.ResponseStatus != nil {
+		code = event.ResponseStatus.Code
+	}
+	a.StatusCodeToCount[code]++
+}
+
+// AnalyzeNamespaceDeletion finds the DELETE of the namespace and the requests that followed it.  The events are
+// expected to be sorted by time.
+func AnalyzeNamespaceDeletion(namespace string, events []*auditv1.Event) (*NamespaceDeletion, error) {
+	ret := &NamespaceDeletion{Namespace: namespace}
+
+	var lastDelete *auditv1.Event
+	for _, event := range events {
+		_, gvr, name, subresource := URIToParts(event.RequestURI)
+		if event.Verb == "delete" && gvr.Group == "" && gvr.Resource == "namespaces" && name == namespace && len(subresource) == 0 {
+			lastDelete = event
+			if code := ResponseCode(event); code >= 200 && code < 300 {
+				ret.Delete = event
+			}
+		}
+	}
+	if ret.Delete == nil {
+		ret.Delete = lastDelete
+	}
+	if ret.Delete == nil {
+		return nil, fmt.Errorf("no DELETE of namespace %q found", namespace)
+	}
+
+	collectionDeletes := map[string]*ResourceActivity{}
+	objectDeletes := map[string]*ResourceActivity{}
+	remaining := map[string]*ResourceActivity{}
+	for _, event := range events {
+		if event.RequestReceivedTimestamp.Before(&ret.Delete.RequestReceivedTimestamp) {
+			continue
+		}
+		ns, gvr, name, subresource := URIToParts(event.RequestURI)
+		if ns != namespace {
+			continue
+		}
+
+		if gvr.Group == "" && gvr.Resource == "namespaces" {
+			if name == namespace && (subresource == "finalize" || subresource == "status") && isWriteVerb(event.Verb) {
+				ret.NamespaceUpdates = append(ret.NamespaceUpdates, event)
+			}
+			continue
+		}
+
+		resource := gvr.GroupResource().String()
+		if len(subresource) > 0 {
+			resource = resource + "/" + subresource
+		}
+		switch {
+		case event.Verb == "deletecollection" && event.User.Username == namespaceControllerUser:
+			if _, ok := collectionDeletes[resource]; !ok {
+				collectionDeletes[resource] = newResourceActivity(resource, event.User.Username)
+			}
+			collectionDeletes[resource].addEvent(event)
+
+		case event.Verb == "delete" && event.User.Username == namespaceControllerUser:
+			if _, ok := objectDeletes[resource]; !ok {
+				objectDeletes[resource] = newResourceActivity(resource, event.User.Username)
+			}
+			objectDeletes[resource].addEvent(event)
+
+		case event.User.Username != namespaceControllerUser && isWriteVerb(event.Verb):
+			key := resource + " " + event.User.Username
+			if _, ok := remaining[key]; !ok {
+				remaining[key] = newResourceActivity(resource, event.User.Username)
+			}
+			remaining[key].addEvent(event)
+		}
+	}
+
+	for _, activity := range collectionDeletes {
+		ret.CollectionDeletes = append(ret.CollectionDeletes, activity)
+	}
+	sort.Slice(ret.CollectionDeletes, func(i, j int) bool {
+		return ret.CollectionDeletes[i].Resource < ret.CollectionDeletes[j].Resource
+	})
+	for _, activity := range objectDeletes {
+		ret.ObjectDeletes = append(ret.ObjectDeletes, activity)
+	}
+	sort.Slice(ret.ObjectDeletes, func(i, j int) bool {
+		return ret.ObjectDeletes[i].Resource < ret.ObjectDeletes[j].Resource
+	})
+	for _, activity := range remaining {
+		ret.RemainingActivity = append(ret.RemainingActivity, activity)
+	}
+	// the resources touched last are the most likely to be holding the namespace
+	sort.Slice(ret.RemainingActivity, func(i, j int) bool {
+		return ret.RemainingActivity[i].Last.After(ret.RemainingActivity[j].Last)
+	})
+
+	return ret, nil
+}
+
+func newResourceActivity(resource, user string) *ResourceActivity {
+	return &ResourceActivity{
+		Resource:          resource,
+		User:              user,
+		Verbs:             sets.NewString(),
+		StatusCodeToCount: map[int32]int{},
+	}
+}
+
+func isWriteVerb(verb string) bool {
+	switch verb {
+	case "create", "update", "patch", "delete", "deletecollection":
+		return true
+	}
+	return false
+}
+
+// ResponseCode is the HTTP status code of the response, 0 when the request did not complete.
+func ResponseCode(event *auditv1.Event) int32 {
+	if event.ResponseStatus == nil {
+		return 0
+	}
+	return event.ResponseStatus.Code
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestAnalyzeNamespaceDeletion(t *testing.T) {
+	start := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	newEvent := func(offset time.Duration, user, verb, uri string, code int32) *auditv1.Event {
+		return &auditv1.Event{
+			Verb:                     verb,
+			RequestURI:               uri,
+			User:                     authnv1.UserInfo{Username: user},
+			ResponseStatus:           &metav1.Status{Code: code},
+			RequestReceivedTimestamp: metav1.NewMicroTime(start.Add(offset)),
+		}
+	}
+	events := []*auditv1.Event{
+		// an earlier write and a DELETE that failed on a conflict
+		newEvent(0, "alice", "create", "/api/v1/namespaces/foo/configmaps", 201),
+		newEvent(time.Second, "alice", "delete", "/api/v1/namespaces/foo", 409),
+		newEvent(2*time.Second, "bob", "update", "/api/v1/namespaces/foo/configmaps/bar", 200),
+		newEvent(3*time.Second, "alice", "delete", "/api/v1/namespaces/foo", 200),
+		newEvent(4*time.Second, namespaceControllerUser, "deletecollection", "/api/v1/namespaces/foo/configmaps", 200),
+		newEvent(5*time.Second, namespaceControllerUser, "delete", "/apis/example.com/v1/namespaces/foo/widgets/a", 200),
+		newEvent(6*time.Second, namespaceControllerUser, "delete", "/apis/example.com/v1/namespaces/foo/widgets/b", 200),
+		newEvent(7*time.Second, "operator", "patch", "/apis/example.com/v1/namespaces/foo/widgets/a", 200),
+		newEvent(8*time.Second, namespaceControllerUser, "update", "/api/v1/namespaces/foo/finalize", 200),
+		// another namespace
+		newEvent(9*time.Second, "alice", "delete", "/api/v1/namespaces/other", 200),
+	}
+
+	deletion, err := AnalyzeNamespaceDeletion("foo", events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deletion.Delete != events[3] {
+		t.Errorf("expected the successful DELETE, got %q at %v [%d]", deletion.Delete.RequestURI, deletion.Delete.RequestReceivedTimestamp, ResponseCode(deletion.Delete))
+	}
+	if len(deletion.NamespaceUpdates) != 1 {
+		t.Errorf("expected the finalize update, got %d updates", len(deletion.NamespaceUpdates))
+	}
+	if len(deletion.CollectionDeletes) != 1 || deletion.CollectionDeletes[0].Resource != "configmaps" {
+		t.Errorf("unexpected collection deletes %v", deletion.CollectionDeletes)
+	}
+	if len(deletion.ObjectDeletes) != 1 || deletion.ObjectDeletes[0].Resource != "widgets.example.com" || deletion.ObjectDeletes[0].Count != 2 {
+		t.Errorf("unexpected object deletes %v", deletion.ObjectDeletes)
+	}
+	// the update of bob happened before the successful DELETE
+	if len(deletion.RemainingActivity) != 1 || deletion.RemainingActivity[0].User != "operator" {
+		t.Errorf("unexpected remaining activity %v", deletion.RemainingActivity)
+	}
+}
+
+func TestAnalyzeNamespaceDeletionWithoutSuccessfulDelete(t *testing.T) {
+	events := []*auditv1.Event{
+		{Verb: "delete", RequestURI: "/api/v1/namespaces/foo", ResponseStatus: &metav1.Status{Code: 404}},
+		{Verb: "delete", RequestURI: "/api/v1/namespaces/foo", ResponseStatus: &metav1.Status{Code: 409}},
+	}
+	deletion, err := AnalyzeNamespaceDeletion("foo", events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deletion.Delete != events[1] {
+		t.Errorf("expected the last DELETE, got the one with %d", ResponseCode(deletion.Delete))
+	}
+	if _, err := AnalyzeNamespaceDeletion("bar", events); err == nil {
+		t.Errorf("expected an error without a DELETE")
+	}
+}
@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	"k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/audit/policy"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+// PolicyVolume is the estimated audit volume of a set of requests.
+type PolicyVolume struct {
+	Requests int
+	// Events is the number of audit events after the omitted stages are removed.
+	Events int
+	Bytes  int64
+	// MissingBodies counts the requests that would log a body that was not recorded, their bytes are underestimated.
+	MissingBodies int
+}
+
+func (v *PolicyVolume) add(other PolicyVolume) {
+	v.Requests += other.Requests
+	v.Events += other.Events
+	v.Bytes += other.Bytes
+	v.MissingBodies += other.MissingBodies
+}
+
+// PolicyRuleSimulation is the volume of the requests that matched one rule first.
+type PolicyRuleSimulation struct {
+	// Index of the rule in the policy, -1 for the requests that didn't match any rule.
+	Index int
+	Rule  *auditinternal.PolicyRule
+	PolicyVolume
+}
+
+// PolicySimulation is the outcome of evaluating a policy against recorded requests.
+type PolicySimulation struct {
+	Rules   []*PolicyRuleSimulation
+	Levels  map[auditinternal.Level]*PolicyVolume
+	Dropped map[string]int
+}
+
+// SimulatePolicy evaluates every recorded request against the policy, using the rule matching of the kube-apiserver.
+// Every request should appear once, so the events are expected to be filtered to a single stage.
+func SimulatePolicy(auditPolicy *auditinternal.Policy, events []*auditv1.Event) *PolicySimulation {
+	evaluator := policy.NewPolicyRuleEvaluator(auditPolicy.DeepCopy())
+
+	// The evaluator doesn't tell which rule matched, so every rule is also wrapped in its own policy.  The probe always
+	// logs at Metadata so that a match can be told apart from the default None level.
+	probes := []audit.PolicyRuleEvaluator{}
+	ret := &PolicySimulation{
+		Levels:  map[auditinternal.Level]*PolicyVolume{},
+		Dropped: map[string]int{},
+	}
+	for i := range auditPolicy.Rules {
+		rule := auditPolicy.Rules[i].DeepCopy()
+		rule.Level = auditinternal.LevelMetadata
+		probes = append(probes, policy.NewPolicyRuleEvaluator(&auditinternal.Policy{Rules: []auditinternal.PolicyRule{*rule}}))
+		ret.Rules = append(ret.Rules, &PolicyRuleSimulation{Index: i, Rule: &auditPolicy.Rules[i]})
+	}
+	defaultRule := &PolicyRuleSimulation{Index: -1}
+	ret.Rules = append(ret.Rules, defaultRule)
+
+	for _, event := range events {
+		attributes := eventToAttributes(event)
+		config := evaluator.EvaluatePolicyRule(attributes)
+
+		matched := defaultRule
+		for i, probe := range probes {
+			if probe.EvaluatePolicyRule(attributes).Level != auditinternal.LevelNone {
+				matched = ret.Rules[i]
+				break
+			}
+		}
+
+		volume := estimateVolume(event, config)
+		matched.add(volume)
+		if _, ok := ret.Levels[config.Level]; !ok {
+			ret.Levels[config.Level] = &PolicyVolume{}
+		}
+		ret.Levels[config.Level].add(volume)
+
+		if config.Level == auditinternal.LevelNone {
+			_, gvr, _, _ := URIToParts(event.RequestURI)
+			ret.Dropped[fmt.Sprintf("%s %s [%s]", strings.ToUpper(event.Verb), gvr.GroupResource().String(), event.User.Username)]++
+		}
+	}
+
+	return ret
+}
+
+// eventToAttributes rebuilds the authorizer attributes the policy checker matches on.
+func eventToAttributes(event *auditv1.Event) authorizer.Attributes {
+	ns, gvr, name, subresource := URIToParts(event.RequestURI)
+	attributes := authorizer.AttributesRecord{
+		User: &user.DefaultInfo{
+			Name:   event.User.Username,
+			UID:    event.User.UID,
+			Groups: event.User.Groups,
+		},
+		Verb:            event.Verb,
+		Namespace:       ns,
+		APIGroup:        gvr.Group,
+		APIVersion:      gvr.Version,
+		Resource:        gvr.Resource,
+		Subresource:     subresource,
+		Name:            name,
+		ResourceRequest: len(gvr.Resource) > 0,
+		Path:            strings.SplitN(event.RequestURI, "?", 2)[0],
+	}
+	if ref := event.ObjectRef; ref != nil {
+		attributes.ResourceRequest = true
+		attributes.Namespace = ref.Namespace
+		attributes.APIGroup = ref.APIGroup
+		attributes.APIVersion = ref.APIVersion
+		attributes.Resource = ref.Resource
+		attributes.Subresource = ref.Subresource
+		attributes.Name = ref.Name
+	}
+	return attributes
+}
+
+// estimateVolume estimates the events and bytes a request would produce with the given audit config.
+func estimateVolume(event *auditv1.Event, config audit.RequestAuditConfig) PolicyVolume {
+	ret := PolicyVolume{Requests: 1}
+	if config.Level == auditinternal.LevelNone {
+		return ret
+	}
+
+	stages := []auditinternal.Stage{auditinternal.StageRequestReceived, auditinternal.StageResponseComplete}
+	if event.Verb == "watch" {
+		stages = append(stages, auditinternal.StageResponseStarted)
+	}
+	omitted := map[auditinternal.Stage]bool{}
+	for _, stage := range config.OmitStages {
+		omitted[stage] = true
+	}
+	for _, stage := range stages {
+		if !omitted[stage] {
+			ret.Events++
+		}
+	}
+
+	metadata := event.DeepCopy()
+	metadata.RequestObject = nil
+	metadata.ResponseObject = nil
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return ret
+	}
+	ret.Bytes = int64(ret.Events * len(metadataBytes))
+
+	recordedLevel := auditinternal.Level(event.Level)
+	if config.Level.GreaterOrEqual(auditinternal.LevelRequest) && isWriteVerb(event.Verb) && event.Verb != "delete" {
+		if event.RequestObject != nil {
+			ret.Bytes += int64(len(event.RequestObject.Raw))
+		} else if recordedLevel.Less(auditinternal.LevelRequest) {
+			ret.MissingBodies++
+		}
+	}
+	if config.Level.GreaterOrEqual(auditinternal.LevelRequestResponse) {
+		if event.ResponseObject != nil {
+			ret.Bytes += int64(len(event.ResponseObject.Raw))
+		} else if recordedLevel.Less(auditinternal.LevelRequestResponse) {
+			ret.MissingBodies++
+		}
+	}
+	return ret
+}
@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"testing"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestSimulatePolicy(t *testing.T) {
+	auditPolicy := &auditinternal.Policy{
+		Rules: []auditinternal.PolicyRule{
+			// a narrow rule before the broad rule that also matches its requests
+			{
+				Level:     auditinternal.LevelNone,
+				Users:     []string{"system:kube-proxy"},
+				Verbs:     []string{"watch"},
+				Resources: []auditinternal.GroupResources{{Resources: []string{"endpoints"}}},
+			},
+			{
+				Level:     auditinternal.LevelMetadata,
+				Resources: []auditinternal.GroupResources{{Resources: []string{"secrets"}}},
+			},
+			{
+				Level:     auditinternal.LevelRequestResponse,
+				Resources: []auditinternal.GroupResources{{Group: ""}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		event *auditv1.Event
+		index int
+		level auditinternal.Level
+	}{
+		{
+			name:  "narrow rule first",
+			event: &auditv1.Event{Verb: "watch", RequestURI: "/api/v1/endpoints?watch=true", User: authnv1.UserInfo{Username: "system:kube-proxy"}},
+			index: 0,
+			level: auditinternal.LevelNone,
+		},
+		{
+			name:  "broad rule for other users",
+			event: &auditv1.Event{Verb: "watch", RequestURI: "/api/v1/endpoints?watch=true", User: authnv1.UserInfo{Username: "bob"}},
+			index: 2,
+			level: auditinternal.LevelRequestResponse,
+		},
+		{
+			name:  "earlier rule wins over the broad rule",
+			event: &auditv1.Event{Verb: "get", RequestURI: "/api/v1/namespaces/foo/secrets/bar", User: authnv1.UserInfo{Username: "system:kube-proxy"}},
+			index: 1,
+			level: auditinternal.LevelMetadata,
+		},
+		{
+			name:  "no rule",
+			event: &auditv1.Event{Verb: "get", RequestURI: "/apis/apps/v1/namespaces/foo/deployments/bar", User: authnv1.UserInfo{Username: "bob"}},
+			index: -1,
+			level: auditinternal.LevelNone,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			simulation := SimulatePolicy(auditPolicy, []*auditv1.Event{test.event})
+
+			for _, rule := range simulation.Rules {
+				expected := 0
+				if rule.Index == test.index {
+					expected = 1
+				}
+				if rule.Requests != expected {
+					t.Errorf("expected rule %d to match %d requests, got %d", rule.Index, expected, rule.Requests)
+				}
+			}
+			if len(simulation.Levels) != 1 || simulation.Levels[test.level] == nil {
+				t.Errorf("expected level %s, got %v", test.level, simulation.Levels)
+			}
+		})
+	}
+}
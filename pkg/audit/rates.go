@@ -0,0 +1,199 @@
+package audit
+
+import (
+	"sort"
+	"time"
+
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+// minBurstRate ignores bursts of clients that are barely active, a client going from 0.01 to 0.1 qps is not interesting.
+const minBurstRate = 1.0
+
+// RateOptions configures the anomaly detection.
+type RateOptions struct {
+	// By is either "user" or "useragent".
+	By string
+	// Window is the size of the sliding window the rate is computed over.
+	Window time.Duration
+	// BurstFactor flags windows where the rate is higher than this multiple of the client's own baseline.
+	BurstFactor float64
+	// SustainedQPS flags windows where the rate is higher than this, client-go defaults to 5 qps with a burst of 10.
+	SustainedQPS float64
+}
+
+// RateAnomaly is a time range where a client issued requests faster than expected.
+type RateAnomaly struct {
+	// Kind is either "burst" or "sustained".
+	Kind   string
+	Client string
+	Start  time.Time
+	End    time.Time
+	// PeakQPS is the highest rate observed over a single window of the range.
+	PeakQPS float64
+	// BaselineQPS is the median rate of the client over all its windows, at least its mean rate over the time it was
+	// active.
+	BaselineQPS float64
+	Requests    int
+	// Excess is the number of requests above the baseline, anomalies are ranked by it.
+	Excess float64
+}
+
+// FindRateAnomalies computes the request rate of every client over a window sliding by one second.  It flags the
+// ranges where the rate exceeds a multiple of the client's baseline rate and the ranges where the rate stays above the
+// sustained threshold.  The anomalies are ranked by the number of requests in excess of the baseline.
+func FindRateAnomalies(events []*auditv1.Event, options RateOptions) []*RateAnomaly {
+	clientToSeconds := map[string]map[int64]int{}
+	for _, event := range events {
+		client := event.User.Username
+		if options.By == "useragent" {
+			client = event.UserAgent
+		}
+		if _, ok := clientToSeconds[client]; !ok {
+			clientToSeconds[client] = map[int64]int{}
+		}
+		clientToSeconds[client][event.RequestReceivedTimestamp.Unix()]++
+	}
+
+	windowSeconds := int64(options.Window / time.Second)
+	if windowSeconds < 1 {
+		windowSeconds = 1
+	}
+
+	ret := []*RateAnomaly{}
+	for client, seconds := range clientToSeconds {
+		ret = append(ret, findClientRateAnomalies(client, seconds, windowSeconds, options)...)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Excess != ret[j].Excess {
+			return ret[i].Excess > ret[j].Excess
+		}
+		return ret[i].Start.Before(ret[j].Start)
+	})
+	return ret
+}
+
+// rateSegment is a run of windows with the same number of requests, the windows starting in [start, end).
+type rateSegment struct {
+	start, end int64
+	count      int
+}
+
+// rateSegments splits the windows starting between the first and the last active second of a client into runs of
+// windows with the same number of requests.  The count only changes when a window starts to include or stops including
+// an active second, so the cost is proportional to the active seconds rather than to the span of the client.
+func rateSegments(seconds map[int64]int, windowSeconds int64) []rateSegment {
+	first, last := int64(0), int64(0)
+	changes := map[int64]int{}
+	for second, count := range seconds {
+		if first == 0 || second < first {
+			first = second
+		}
+		if second > last {
+			last = second
+		}
+		changes[second-windowSeconds+1] += count
+		changes[second+1] -= count
+	}
+	points := make([]int64, 0, len(changes))
+	for point := range changes {
+		points = append(points, point)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i] < points[j]
+	})
+
+	ret := []rateSegment{}
+	current := 0
+	for i, point := range points {
+		current += changes[point]
+		start, end := point, last+1
+		if i+1 < len(points) && points[i+1] < end {
+			end = points[i+1]
+		}
+		if start < first {
+			start = first
+		}
+		if start < end {
+			ret = append(ret, rateSegment{start: start, end: end, count: current})
+		}
+	}
+	return ret
+}
+
+func findClientRateAnomalies(client string, seconds map[int64]int, windowSeconds int64, options RateOptions) []*RateAnomaly {
+	segments := rateSegments(seconds, windowSeconds)
+
+	// the median window, segments are weighted by the number of windows they hold
+	sorted := append([]rateSegment{}, segments...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].count < sorted[j].count
+	})
+	windows := int64(0)
+	for _, segment := range segments {
+		windows += segment.end - segment.start
+	}
+	median, seen := 0, int64(0)
+	for _, segment := range sorted {
+		seen += segment.end - segment.start
+		if seen > windows/2 {
+			median = segment.count
+			break
+		}
+	}
+	baseline := float64(median) / float64(windowSeconds)
+	// the median is 0 for clients that are idle most of the time, the mean keeps their periodic requests from being
+	// flagged as bursts
+	requests := 0
+	for _, count := range seconds {
+		requests += count
+	}
+	if mean := float64(requests) / float64(windows); mean > baseline {
+		baseline = mean
+	}
+
+	isBurst := func(rate float64) bool {
+		return rate >= minBurstRate && rate > baseline*options.BurstFactor
+	}
+	isSustained := func(rate float64) bool {
+		return rate > options.SustainedQPS
+	}
+
+	ret := []*RateAnomaly{}
+	for _, detector := range []struct {
+		kind    string
+		matches func(float64) bool
+	}{{kind: "burst", matches: isBurst}, {kind: "sustained", matches: isSustained}} {
+		var anomaly *RateAnomaly
+		for _, segment := range segments {
+			rate := float64(segment.count) / float64(windowSeconds)
+			if !detector.matches(rate) {
+				if anomaly != nil {
+					ret = append(ret, finishRateAnomaly(anomaly, seconds))
+					anomaly = nil
+				}
+				continue
+			}
+			if anomaly == nil {
+				anomaly = &RateAnomaly{Kind: detector.kind, Client: client, Start: time.Unix(segment.start, 0), BaselineQPS: baseline}
+			}
+			// the last window of the segment starts at end-1
+			anomaly.End = time.Unix(segment.end-1+windowSeconds, 0)
+			if rate > anomaly.PeakQPS {
+				anomaly.PeakQPS = rate
+			}
+		}
+		if anomaly != nil {
+			ret = append(ret, finishRateAnomaly(anomaly, seconds))
+		}
+	}
+	return ret
+}
+
+func finishRateAnomaly(anomaly *RateAnomaly, seconds map[int64]int) *RateAnomaly {
+	for second := anomaly.Start.Unix(); second < anomaly.End.Unix(); second++ {
+		anomaly.Requests += seconds[second]
+	}
+	anomaly.Excess = float64(anomaly.Requests) - anomaly.BaselineQPS*anomaly.End.Sub(anomaly.Start).Seconds()
+	return anomaly
+}
@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestFindRateAnomaliesBursts(t *testing.T) {
+	start := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	newRequests := func(user string, offset time.Duration, count int) []*auditv1.Event {
+		ret := []*auditv1.Event{}
+		for i := 0; i < count; i++ {
+			ret = append(ret, &auditv1.Event{
+				Verb:                     "list",
+				RequestURI:               "/api/v1/namespaces/foo/configmaps",
+				User:                     authnv1.UserInfo{Username: user},
+				RequestReceivedTimestamp: metav1.NewMicroTime(start.Add(offset)),
+			})
+		}
+		return ret
+	}
+
+	events := []*auditv1.Event{}
+	// resyncs of 20 requests every 30 seconds for 10 minutes, idle in between, the median rate is 0
+	for offset := time.Duration(0); offset <= 10*time.Minute; offset += 30 * time.Second {
+		events = append(events, newRequests("sparse", offset, 20)...)
+		events = append(events, newRequests("bursty", offset, 20)...)
+	}
+	// and a real burst
+	events = append(events, newRequests("bursty", 5*time.Minute+45*time.Second, 200)...)
+
+	options := RateOptions{By: "user", Window: 10 * time.Second, BurstFactor: 5, SustainedQPS: 100}
+	anomalies := FindRateAnomalies(events, options)
+	if len(anomalies) != 1 {
+		t.Fatalf("expected one anomaly, got %d", len(anomalies))
+	}
+	anomaly := anomalies[0]
+	if anomaly.Kind != "burst" || anomaly.Client != "bursty" || anomaly.Requests != 200 {
+		t.Errorf("expected a burst of 200 requests by bursty, got a %s of %d requests by %s", anomaly.Kind, anomaly.Requests, anomaly.Client)
+	}
+	if anomaly.BaselineQPS == 0 {
+		t.Errorf("expected the baseline to be the mean rate, got 0")
+	}
+}
+
+func TestFindRateAnomaliesSustained(t *testing.T) {
+	start := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	events := []*auditv1.Event{}
+	// 10 qps for 30 seconds, then idle for a day and a single request
+	for second := 0; second < 30; second++ {
+		for i := 0; i < 10; i++ {
+			events = append(events, &auditv1.Event{
+				Verb:                     "get",
+				RequestURI:               "/api/v1/namespaces/foo/configmaps/bar",
+				UserAgent:                "poller/v1",
+				RequestReceivedTimestamp: metav1.NewMicroTime(start.Add(time.Duration(second) * time.Second)),
+			})
+		}
+	}
+	events = append(events, &auditv1.Event{
+		Verb:                     "get",
+		RequestURI:               "/api/v1/namespaces/foo/configmaps/bar",
+		UserAgent:                "poller/v1",
+		RequestReceivedTimestamp: metav1.NewMicroTime(start.Add(24 * time.Hour)),
+	})
+
+	options := RateOptions{By: "useragent", Window: 10 * time.Second, BurstFactor: 5, SustainedQPS: 5}
+	sustained := []*RateAnomaly{}
+	for _, anomaly := range FindRateAnomalies(events, options) {
+		// next to the day of idle time, the 30 seconds are a burst too
+		if anomaly.Kind == "sustained" {
+			sustained = append(sustained, anomaly)
+		}
+	}
+	if len(sustained) != 1 {
+		t.Fatalf("expected one sustained anomaly, got %d", len(sustained))
+	}
+	anomaly := sustained[0]
+	if anomaly.Kind != "sustained" || anomaly.Client != "poller/v1" || anomaly.Requests != 300 || anomaly.PeakQPS != 10 {
+		t.Errorf("expected 300 requests at 10 qps by poller/v1, got a %s of %d requests at %v qps by %s", anomaly.Kind, anomaly.Requests, anomaly.PeakQPS, anomaly.Client)
+	}
+	if !anomaly.Start.Equal(start) || !anomaly.End.Equal(start.Add(34*time.Second)) {
+		t.Errorf("expected the windows with more than 5 qps, got %v - %v", anomaly.Start, anomaly.End)
+	}
+}
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+// sensitiveSubresources are the subresources that give access to a workload or a node.
+var sensitiveSubresources = sets.NewString("pods/exec", "pods/attach", "pods/portforward", "nodes/proxy")
+
+// SensitiveAccess aggregates the reads of secrets and the exec like sessions of one user in one namespace.
+type SensitiveAccess struct {
+	User      string
+	Namespace string
+	// Access is either "secrets <verb>" or the subresource, eg. "pods/exec".
+	Access            string
+	Human             bool
+	Count             int
+	Names             sets.String
+	StatusCodeToCount map[int32]int
+	First             time.Time
+	Last              time.Time
+}
+
+// BuildSensitiveAccessReport finds every get, list and watch of secrets and every pods/exec, pods/attach,
+// pods/portforward and nodes/proxy request.  Humans are sorted first, then the most frequent accesses.
+func BuildSensitiveAccessReport(events []*auditv1.Event) []*SensitiveAccess {
+	accesses := map[string]*SensitiveAccess{}
+	for _, event := range events {
+		ns, gvr, name, subresource := URIToParts(event.RequestURI)
+		if event.ObjectRef != nil {
+			// the objectRef is more reliable for the subresources and names
+			ns = event.ObjectRef.Namespace
+			if len(event.ObjectRef.Name) > 0 {
+				name = event.ObjectRef.Name
+			}
+			if len(event.ObjectRef.Subresource) > 0 {
+				subresource = event.ObjectRef.Subresource
+			}
+		}
+		if len(gvr.Group) > 0 {
+			continue
+		}
+
+		var access string
+		switch {
+		case gvr.Resource == "secrets" && len(subresource) == 0 && (event.Verb == "get" || event.Verb == "list" || event.Verb == "watch"):
+			access = "secrets " + event.Verb
+		case sensitiveSubresources.Has(gvr.Resource + "/" + strings.SplitN(subresource, "/", 2)[0]):
+			// nodes/proxy carries the proxied path, eg. nodes/<name>/proxy/logs
+			access = gvr.Resource + "/" + strings.SplitN(subresource, "/", 2)[0]
+		default:
+			continue
+		}
+
+		key := strings.Join([]string{event.User.Username, ns, access}, "|")
+		current, ok := accesses[key]
+		if !ok {
+			current = &SensitiveAccess{
+				User:              event.User.Username,
+				Namespace:         ns,
+				Access:            access,
+				Human:             isHumanUser(event.User.Username),
+				Names:             sets.NewString(),
+				StatusCodeToCount: map[int32]int{},
+				First:             event.RequestReceivedTimestamp.Time,
+			}
+			accesses[key] = current
+		}
+		current.Count++
+		if len(name) > 0 {
+			current.Names.Insert(name)
+		}
+		current.StatusCodeToCount[ResponseCode(event)]++
+		if event.RequestReceivedTimestamp.Time.Before(current.First) {
+			current.First = event.RequestReceivedTimestamp.Time
+		}
+		if event.RequestReceivedTimestamp.Time.After(current.Last) {
+			current.Last = event.RequestReceivedTimestamp.Time
+		}
+	}
+
+	ret := []*SensitiveAccess{}
+	for _, access := range accesses {
+		ret = append(ret, access)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Human != ret[j].Human {
+			return ret[i].Human
+		}
+		if ret[i].Count != ret[j].Count {
+			return ret[i].Count > ret[j].Count
+		}
+		return strings.Join([]string{ret[i].User, ret[i].Namespace, ret[i].Access}, "|") < strings.Join([]string{ret[j].User, ret[j].Namespace, ret[j].Access}, "|")
+	})
+	return ret
+}
+
+// isHumanUser is true for users that are neither service accounts nor system components.
+func isHumanUser(username string) bool {
+	return !strings.HasPrefix(username, "system:")
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"testing"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestIsHumanUser(t *testing.T) {
+	tests := map[string]bool{
+		"alice":                          true,
+		"kube:admin":                     true,
+		"system:admin":                   false,
+		"system:serviceaccount:foo:bar":  false,
+		"system:node:worker-1":           false,
+		"system:kube-controller-manager": false,
+		"system:apiserver":               false,
+	}
+	for username, expected := range tests {
+		if actual := isHumanUser(username); actual != expected {
+			t.Errorf("expected %q to be human: %v", username, expected)
+		}
+	}
+}
+
+func TestBuildSensitiveAccessReport(t *testing.T) {
+	newEvent := func(user, verb, uri string, code int32) *auditv1.Event {
+		return &auditv1.Event{
+			Verb:           verb,
+			RequestURI:     uri,
+			User:           authnv1.UserInfo{Username: user},
+			ResponseStatus: &metav1.Status{Code: code},
+		}
+	}
+	events := []*auditv1.Event{
+		newEvent("system:serviceaccount:foo:bar", "get", "/api/v1/namespaces/foo/secrets/a", 200),
+		newEvent("system:serviceaccount:foo:bar", "get", "/api/v1/namespaces/foo/secrets/b", 200),
+		newEvent("system:serviceaccount:foo:bar", "get", "/api/v1/namespaces/foo/secrets/c", 200),
+		newEvent("alice", "get", "/api/v1/namespaces/foo/secrets/a", 403),
+		newEvent("alice", "create", "/api/v1/namespaces/foo/pods/bar/exec?command=sh", 101),
+		newEvent("alice", "get", "/api/v1/nodes/worker-1/proxy/logs/journal", 200),
+		// not sensitive: the writes of secrets, the other subresources and the other resources
+		newEvent("alice", "update", "/api/v1/namespaces/foo/secrets/a", 200),
+		newEvent("alice", "get", "/api/v1/namespaces/foo/pods/bar/log", 200),
+		newEvent("alice", "get", "/api/v1/namespaces/foo/configmaps/a", 200),
+	}
+
+	report := BuildSensitiveAccessReport(events)
+	expected := []struct {
+		user      string
+		namespace string
+		access    string
+		count     int
+	}{
+		// the humans are first, then the most frequent accesses
+		{user: "alice", namespace: "foo", access: "pods/exec", count: 1},
+		{user: "alice", namespace: "foo", access: "secrets get", count: 1},
+		{user: "alice", namespace: "", access: "nodes/proxy", count: 1},
+		{user: "system:serviceaccount:foo:bar", namespace: "foo", access: "secrets get", count: 3},
+	}
+	if len(report) != len(expected) {
+		t.Fatalf("expected %d accesses, got %d", len(expected), len(report))
+	}
+	for i := range expected {
+		actual := report[i]
+		if actual.User != expected[i].user || actual.Namespace != expected[i].namespace || actual.Access != expected[i].access || actual.Count != expected[i].count {
+			t.Errorf("%d: expected %v, got %s %q %s %d", i, expected[i], actual.User, actual.Namespace, actual.Access, actual.Count)
+		}
+	}
+	if names := report[3].Names.List(); len(names) != 3 {
+		t.Errorf("expected the 3 secrets read by the service account, got %v", names)
+	}
+	if report[1].StatusCodeToCount[403] != 1 {
+		t.Errorf("expected the forbidden read of alice, got %v", report[1].StatusCodeToCount)
+	}
+}
@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"encoding/json"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+// EtcdRequestSizeLimit is the default --max-request-bytes of etcd, writing a bigger object fails.
+const EtcdRequestSizeLimit = 1536 * 1024
+
+// SizeStats are the body sizes of the requests of a user with the same verb and resource.
+type SizeStats struct {
+	User     string
+	Verb     string
+	Resource schema.GroupResource
+
+	Count            int
+	RequestBytes     int64
+	MaxRequestBytes  int64
+	ResponseBytes    int64
+	MaxResponseBytes int64
+}
+
+// ObjectSize is the size of the body of a single request.
+type ObjectSize struct {
+	Event     *auditv1.Event
+	Resource  schema.GroupResource
+	Namespace string
+	Name      string
+	Bytes     int64
+	// Items is the number of items of a list response, -1 when it could not be read.
+	Items int
+}
+
+// SizeReport describes the request and response bodies of audit logs captured at the RequestResponse level.  The
+// sizes are the sizes of the JSON of the audit log, objects stored as protobuf take less space in etcd.
+type SizeReport struct {
+	// EventsWithBodies is the number of events with a request or a response body, it is zero when the audit policy
+	// doesn't log at the Request or RequestResponse level.
+	EventsWithBodies int
+	// Stats are sorted by the bytes transferred, highest first.
+	Stats []*SizeStats
+	// LargestWrites are the largest objects created, updated or patched, largest first.
+	LargestWrites []*ObjectSize
+	// LargestLists are the largest list responses, largest first.
+	LargestLists []*ObjectSize
+}
+
+func bodySize(event *auditv1.Event) (int64, int64) {
+	var request, response int64
+	if event.RequestObject != nil {
+		request = int64(len(event.RequestObject.Raw))
+	}
+	if event.ResponseObject != nil {
+		response = int64(len(event.ResponseObject.Raw))
+	}
+	return request, response
+}
+
+func objectRefParts(event *auditv1.Event) (schema.GroupResource, string, string) {
+	if event.ObjectRef != nil {
+		return schema.GroupResource{Group: event.ObjectRef.APIGroup, Resource: event.ObjectRef.Resource}, event.ObjectRef.Namespace, event.ObjectRef.Name
+	}
+	ns, gvr, name, _ := URIToParts(event.RequestURI)
+	return gvr.GroupResource(), ns, name
+}
+
+// writtenObjectSize is the size of the object stored by a write.  The response of a successful write is the stored
+// object, a patch request is only the patch.
+func writtenObjectSize(event *auditv1.Event) int64 {
+	request, response := bodySize(event)
+	if code := ResponseCode(event); code >= 200 && code < 300 && response > 0 {
+		return response
+	}
+	if event.Verb == "patch" {
+		return 0
+	}
+	return request
+}
+
+// listItems counts the items of a list response, -1 when the response is not a list.
+func listItems(event *auditv1.Event) int {
+	if event.ResponseObject == nil {
+		return -1
+	}
+	list := struct {
+		Items []json.RawMessage `json:"items"`
+	}{}
+	if err := json.Unmarshal(event.ResponseObject.Raw, &list); err != nil {
+		return -1
+	}
+	return len(list.Items)
+}
+
+// BuildSizeReport sums the body sizes per user, verb and resource and keeps the numToKeep largest writes and lists,
+// none when numToKeep is negative.
+func BuildSizeReport(events []*auditv1.Event, numToKeep int) *SizeReport {
+	type statsKey struct {
+		user, verb string
+		resource   schema.GroupResource
+	}
+	stats := map[statsKey]*SizeStats{}
+
+	ret := &SizeReport{}
+	for _, event := range events {
+		request, response := bodySize(event)
+		if request == 0 && response == 0 {
+			continue
+		}
+		ret.EventsWithBodies++
+
+		resource, namespace, name := objectRefParts(event)
+		key := statsKey{user: event.User.Username, verb: event.Verb, resource: resource}
+		current, ok := stats[key]
+		if !ok {
+			current = &SizeStats{User: key.user, Verb: key.verb, Resource: resource}
+			stats[key] = current
+		}
+		current.Count++
+		current.RequestBytes += request
+		current.ResponseBytes += response
+		if request > current.MaxRequestBytes {
+			current.MaxRequestBytes = request
+		}
+		if response > current.MaxResponseBytes {
+			current.MaxResponseBytes = response
+		}
+
+		switch event.Verb {
+		case "create", "update", "patch":
+			if written := writtenObjectSize(event); written > 0 {
+				if len(name) == 0 {
+					name = createdObjectName(event)
+				}
+				ret.LargestWrites = append(ret.LargestWrites, &ObjectSize{Event: event, Resource: resource, Namespace: namespace, Name: name, Bytes: written, Items: -1})
+			}
+		case "list":
+			if response > 0 {
+				ret.LargestLists = append(ret.LargestLists, &ObjectSize{Event: event, Resource: resource, Namespace: namespace, Bytes: response})
+			}
+		}
+	}
+
+	for _, current := range stats {
+		ret.Stats = append(ret.Stats, current)
+	}
+	sort.Slice(ret.Stats, func(i, j int) bool {
+		lhs, rhs := ret.Stats[i], ret.Stats[j]
+		if lhs.RequestBytes+lhs.ResponseBytes != rhs.RequestBytes+rhs.ResponseBytes {
+			return lhs.RequestBytes+lhs.ResponseBytes > rhs.RequestBytes+rhs.ResponseBytes
+		}
+		if lhs.User != rhs.User {
+			return lhs.User < rhs.User
+		}
+		if lhs.Verb != rhs.Verb {
+			return lhs.Verb < rhs.Verb
+		}
+		return lhs.Resource.String() < rhs.Resource.String()
+	})
+
+	ret.LargestWrites = largestObjects(ret.LargestWrites, numToKeep)
+	ret.LargestLists = largestObjects(ret.LargestLists, numToKeep)
+	// counting the items decodes the response, only do it for the lists that are kept
+	for _, list := range ret.LargestLists {
+		list.Items = listItems(list.Event)
+	}
+	return ret
+}
+
+func largestObjects(objects []*ObjectSize, numToKeep int) []*ObjectSize {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return objects[i].Bytes > objects[j].Bytes
+	})
+	if numToKeep < 0 {
+		numToKeep = 0
+	}
+	if len(objects) > numToKeep {
+		objects = objects[:numToKeep]
+	}
+	return objects
+}
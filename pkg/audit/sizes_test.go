@@ -0,0 +1,40 @@
+package audit
+
+import (
+	"testing"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestBuildSizeReportNumToKeep(t *testing.T) {
+	events := []*auditv1.Event{
+		{
+			Verb:          "create",
+			RequestURI:    "/api/v1/namespaces/foo/configmaps",
+			User:          authnv1.UserInfo{Username: "alice"},
+			RequestObject: &runtime.Unknown{Raw: []byte(`{"kind":"ConfigMap","apiVersion":"v1","data":{"a":"b"}}`)},
+		},
+		{
+			Verb:          "create",
+			RequestURI:    "/api/v1/namespaces/foo/configmaps",
+			User:          authnv1.UserInfo{Username: "alice"},
+			RequestObject: &runtime.Unknown{Raw: []byte(`{"kind":"ConfigMap","apiVersion":"v1","data":{"a":"bbbbbbbbbbbbbbbb"}}`)},
+		},
+	}
+
+	for _, numToKeep := range []int{-1, 0, 1, 10} {
+		report := BuildSizeReport(events, numToKeep)
+		expected := numToKeep
+		switch {
+		case expected < 0:
+			expected = 0
+		case expected > len(events):
+			expected = len(events)
+		}
+		if len(report.LargestWrites) != expected {
+			t.Errorf("%d: expected %d writes, got %d", numToKeep, expected, len(report.LargestWrites))
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	"k8s.io/klog"
+
+	"github.com/openshift/cluster-debug-tools/pkg/util"
+)
+
+// EventSource provides audit events, eg. the audit logs of a must-gather or a stream of events in a CI job.
+type EventSource interface {
+	// Visit hands every event of the source to visitor and stops at the first error.
+	Visit(visitor func(event *auditv1.Event) error) error
+}
+
+// LoadEvents reads every event of the source, sorted by the time they were received.
+func LoadEvents(source EventSource) ([]*auditv1.Event, error) {
+	ret := []*auditv1.Event{}
+	err := source.Visit(func(event *auditv1.Event) error {
+		ret = append(ret, event)
+		return nil
+	})
+
+	// sort events by time
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].RequestReceivedTimestamp.Time.Before(ret[j].RequestReceivedTimestamp.Time)
+	})
+
+	return ret, err
+}
+
+// EventsTimeSpan returns the time span the events were received in, relative times of the filters are resolved against
+// it.
+func EventsTimeSpan(events []*auditv1.Event) util.TimeSpan {
+	span := util.TimeSpan{}
+	for _, event := range events {
+		span.Include(event.RequestReceivedTimestamp.Time)
+	}
+	return span
+}
+
+// FileSource reads audit logs from disk.  Paths are audit logs, plain or gzipped, or directories of audit logs.
+type FileSource struct {
+	Paths []string
+
+	lock sync.Mutex
+	// readFailures counts the lines that could not be decoded into an event, they are skipped.
+	readFailures int
+}
+
+func NewFileSource(paths ...string) *FileSource {
+	return &FileSource{Paths: paths}
+}
+
+// ReadFailures is the number of lines that could not be decoded into an event by the last Visit.
+func (s *FileSource) ReadFailures() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.readFailures
+}
+
+// Visit decodes the files in parallel, the events are handed to visitor one file at a time in the order of the paths.
+// At most GOMAXPROCS files are decoded or held in memory at a time, the one being visited included, so a slow visitor
+// holds back the decoding.
+func (s *FileSource) Visit(visitor func(event *auditv1.Event) error) error {
+	s.lock.Lock()
+	s.readFailures = 0
+	s.lock.Unlock()
+
+	files := []string{}
+	for _, path := range s.Paths {
+		err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	type fileEvents struct {
+		events []*auditv1.Event
+		err    error
+	}
+	results := make([]chan fileEvents, len(files))
+	for i := range results {
+		results[i] = make(chan fileEvents, 1)
+	}
+	// a slot is taken before a file is decoded and given back once its events were visited
+	slots := make(chan struct{}, runtime.GOMAXPROCS(0))
+	go func() {
+		for i, file := range files {
+			slots <- struct{}{}
+			go func(file string, result chan<- fileEvents) {
+				events := []*auditv1.Event{}
+				failures, err := ScanFile(file, func(event *auditv1.Event, _, _ []byte) error {
+					events = append(events, event)
+					return nil
+				})
+				s.lock.Lock()
+				s.readFailures += failures
+				s.lock.Unlock()
+				result <- fileEvents{events: events, err: err}
+			}(file, results[i])
+		}
+	}()
+
+	var firstErr error
+	for _, result := range results {
+		// every file is waited for, so that no worker outlives Visit
+		current := <-result
+		if firstErr == nil && current.err != nil {
+			firstErr = current.err
+		}
+		if firstErr == nil {
+			for _, event := range current.events {
+				if err := visitor(event); err != nil {
+					firstErr = err
+					break
+				}
+			}
+		}
+		<-slots
+	}
+	return firstErr
+}
+
+// ReaderSource reads a single audit log from a reader, the name is only used in errors.
+type ReaderSource struct {
+	Name   string
+	Reader io.Reader
+}
+
+func (s *ReaderSource) Visit(visitor func(event *auditv1.Event) error) error {
+	_, err := ScanReader(s.Name, s.Reader, func(event *auditv1.Event, _, _ []byte) error {
+		return visitor(event)
+	})
+	return err
+}
+
+// SliceSource serves events that are already in memory, eg. to run the aggregations on events of a test.
+type SliceSource []*auditv1.Event
+
+func (s SliceSource) Visit(visitor func(event *auditv1.Event) error) error {
+	for _, event := range s {
+		if err := visitor(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScanFile hands every audit event of a log, plain or gzipped, to handle with its line and the hostname prefix of the
+// line.  It returns the number of lines that could not be decoded into an event, those are skipped.
+func ScanFile(path string, handle func(event *auditv1.Event, line, prefix []byte) error) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return 0, fmt.Errorf("unable to read %q: %w", path, err)
+		}
+		defer zr.Close()
+		reader = zr
+	}
+	return ScanReader(path, reader, handle)
+}
+
+// ScanReader is ScanFile for a log that is already open, the name is only used in errors.
+func ScanReader(name string, reader io.Reader, handle func(event *auditv1.Event, line, prefix []byte) error) (int, error) {
+	failures, line := 0, 0
+	scanner := newAuditScanner(reader)
+	for scanner.Scan() {
+		line++
+		prefix, event, err := parseAuditLine(scanner.Bytes())
+		if err != nil {
+			failures++
+			klog.V(1).Infof("unable to decode %q line %d to audit event: %v\n", name, line, err)
+			continue
+		}
+		if event == nil {
+			continue
+		}
+		if err := handle(event, scanner.Bytes(), prefix); err != nil {
+			return failures, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return failures, fmt.Errorf("unable to read %q after line %d: %w", name, line, err)
+	}
+	return failures, nil
+}
+
+// maxAuditLineSize is big enough for events logged at RequestResponse level, bufio.Scanner stops at 64KiB by default.
+const maxAuditLineSize = 16 * 1024 * 1024
+
+func newAuditScanner(reader io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxAuditLineSize)
+	return scanner
+}
+
+// parseAuditLine decodes a single line of an audit log.  Each line in audit file use following format:
+// `hostname {JSON}`, the hostname is returned as the prefix and may be empty.  A nil event without an error means the
+// line didn't contain an event.
+func parseAuditLine(auditBytes []byte) ([]byte, *auditv1.Event, error) {
+	var prefix []byte
+	if len(auditBytes) == 0 {
+		return nil, nil, nil
+	}
+	if string(auditBytes[0]) != "{" {
+		// strip the hostname part
+		hostnameEndPos := bytes.Index(auditBytes, []byte(" "))
+		if hostnameEndPos == -1 {
+			// oops something is wrong in the file?
+			return nil, nil, nil
+		}
+
+		prefix = auditBytes[:hostnameEndPos]
+		auditBytes = auditBytes[hostnameEndPos:]
+	}
+
+	// shame, shame shame... we have to copy out the apiserver/apis/audit/v1alpha1.Event because adding it as dependency
+	// will cause mess in flags...
+	eventObj := &auditv1.Event{}
+	if err := json.Unmarshal(auditBytes, eventObj); err != nil {
+		return prefix, nil, err
+	}
+	return prefix, eventObj, nil
+}
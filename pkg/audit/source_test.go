@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestReaderSourceWithFilterBuilder(t *testing.T) {
+	log := strings.Join([]string{
+		`master-0 {"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"1","stage":"ResponseComplete","requestURI":"/api/v1/namespaces/foo/pods/bar","verb":"get","user":{"username":"alice"},"responseStatus":{"code":200},"requestReceivedTimestamp":"2021-01-01T00:00:02.000000Z","stageTimestamp":"2021-01-01T00:00:02.100000Z"}`,
+		`not an event`,
+		`{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"2","stage":"ResponseComplete","requestURI":"/api/v1/namespaces/foo/secrets/baz","verb":"delete","user":{"username":"bob"},"responseStatus":{"code":403},"requestReceivedTimestamp":"2021-01-01T00:00:01.000000Z","stageTimestamp":"2021-01-01T00:00:01.100000Z"}`,
+	}, "\n")
+
+	events, err := LoadEvents(&ReaderSource{Name: "test", Reader: strings.NewReader(log)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].AuditID != "2" {
+		t.Errorf("expected the events sorted by time, got %q first", events[0].AuditID)
+	}
+
+	filters, err := (&FilterBuilder{Resources: []string{"secrets"}, FailedOnly: true}).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	filtered := filters.FilterEvents(events...)
+	if len(filtered) != 1 || filtered[0].User.Username != "bob" {
+		t.Errorf("expected the failed secret deletion, got %v", filtered)
+	}
+
+	if _, err := (&FilterBuilder{Kinds: []string{"Pod"}}).Build(); err == nil {
+		t.Errorf("expected an error for kinds without a resolver")
+	}
+}
+
+func TestFileSourceVisitHoldsBackDecoding(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(2))
+	workers := runtime.GOMAXPROCS(0)
+
+	dir := t.TempDir()
+	numFiles := 5 * workers
+	for i := 0; i < numFiles; i++ {
+		// the line that is not an event counts the decoded files in ReadFailures
+		log := fmt.Sprintf(`{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"%d","stage":"ResponseComplete","requestURI":"/api/v1/pods","verb":"list","user":{"username":"alice"}}`+"\nnot an event\n", i)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("audit-%02d.log", i)), []byte(log), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := NewFileSource(dir)
+	visited := 0
+	err := source.Visit(func(event *auditv1.Event) error {
+		if expected := strconv.Itoa(visited); string(event.AuditID) != expected {
+			return fmt.Errorf("expected event %s, got %s", expected, event.AuditID)
+		}
+		visited++
+		// give the workers the time to run ahead of a slow visitor
+		time.Sleep(10 * time.Millisecond)
+		if decoded := source.ReadFailures(); decoded > visited-1+workers {
+			return fmt.Errorf("%d files decoded while visiting file %d with %d workers", decoded, visited-1, workers)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if visited != numFiles {
+		t.Errorf("expected %d events, got %d", numFiles, visited)
+	}
+}
@@ -0,0 +1,204 @@
+package audit
+
+import (
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog"
+)
+
+// QueryParams parses the query of a request URI.  When the query cannot be parsed, the parameters parsed before the
+// error are returned with the error.
+func QueryParams(uri string) (url.Values, error) {
+	parts := strings.SplitN(uri, "?", 2)
+	if len(parts) < 2 {
+		return url.Values{}, nil
+	}
+	return url.ParseQuery(parts[1])
+}
+
+// queryParams is QueryParams for the aggregations, which make the most of what could be parsed.
+func queryParams(uri string) url.Values {
+	values, err := QueryParams(uri)
+	if err != nil {
+		klog.V(2).Infof("unable to parse the query of %q: %v", uri, err)
+	}
+	return values
+}
+
+// URIToParts splits a request URI into the namespace, resource, name and subresource it targets.
+func URIToParts(uri string) (string, schema.GroupVersionResource, string, string) {
+	ns := ""
+	gvr := schema.GroupVersionResource{}
+	name := ""
+
+	if len(uri) >= 1 {
+		if uri[0] == '/' {
+			uri = uri[1:]
+		}
+	}
+
+	// some request URL has query parameters like: /apis/image.openshift.io/v1/images?limit=500&resourceVersion=0
+	// we are not interested in the query parameters.
+	uri = strings.Split(uri, "?")[0]
+	parts := strings.Split(uri, "/")
+	if len(parts) == 0 {
+		return ns, gvr, name, ""
+	}
+	// /api/v1/namespaces/<name>
+	if parts[0] == "api" {
+		if len(parts) >= 2 {
+			gvr.Version = parts[1]
+		}
+		if len(parts) < 3 {
+			return ns, gvr, name, ""
+		}
+
+		switch {
+		case parts[2] != "namespaces": // cluster scoped request that is not a namespace
+			gvr.Resource = parts[2]
+			if len(parts) >= 5 {
+				return ns, gvr, parts[3], strings.Join(parts[4:], "/")
+			}
+			if len(parts) >= 4 {
+				name = parts[3]
+				return ns, gvr, name, ""
+			}
+		case len(parts) == 3 && parts[2] == "namespaces": // a namespace request /api/v1/namespaces
+			gvr.Resource = parts[2]
+			return "", gvr, "", ""
+
+		case len(parts) == 4 && parts[2] == "namespaces": // a namespace request /api/v1/namespaces/<name>
+			gvr.Resource = parts[2]
+			name = parts[3]
+			ns = parts[3]
+			return ns, gvr, name, ""
+
+		case len(parts) == 5 && parts[2] == "namespaces" && parts[4] == "finalize", // a namespace request /api/v1/namespaces/<name>/finalize
+			len(parts) == 5 && parts[2] == "namespaces" && parts[4] == "status": // a namespace request /api/v1/namespaces/<name>/status
+			gvr.Resource = parts[2]
+			name = parts[3]
+			ns = parts[3]
+			return ns, gvr, name, parts[4]
+
+		default:
+			// this is not a cluster scoped request and not a namespace request we recognize
+		}
+
+		if len(parts) < 4 {
+			return ns, gvr, name, ""
+		}
+
+		ns = parts[3]
+		if len(parts) >= 5 {
+			gvr.Resource = parts[4]
+		}
+		if len(parts) >= 6 {
+			name = parts[5]
+		}
+		if len(parts) >= 7 {
+			return ns, gvr, name, strings.Join(parts[6:], "/")
+		}
+		return ns, gvr, name, ""
+	}
+
+	if parts[0] != "apis" {
+		return ns, gvr, name, ""
+	}
+
+	// /apis/group/v1/namespaces/<name>
+	if len(parts) >= 2 {
+		gvr.Group = parts[1]
+	}
+	if len(parts) >= 3 {
+		gvr.Version = parts[2]
+	}
+	if len(parts) < 4 {
+		return ns, gvr, name, ""
+	}
+
+	if parts[3] != "namespaces" {
+		gvr.Resource = parts[3]
+		if len(parts) >= 6 {
+			return ns, gvr, parts[4], strings.Join(parts[5:], "/")
+		}
+		if len(parts) >= 5 {
+			name = parts[4]
+			return ns, gvr, name, ""
+		}
+	}
+	if len(parts) < 5 {
+		return ns, gvr, name, ""
+	}
+
+	ns = parts[4]
+	if len(parts) >= 6 {
+		gvr.Resource = parts[5]
+	}
+	if len(parts) >= 7 {
+		name = parts[6]
+	}
+	if len(parts) >= 8 {
+		return ns, gvr, name, strings.Join(parts[7:], "/")
+	}
+	return ns, gvr, name, ""
+}
+
+// URIToParts is URIToParts with the scopes of discovery.  Without them, /api/v1/namespaces/<name>/<segment> is read
+// as a namespaced resource unless the segment is finalize or status.  When the discovery of the core group is known, a
+// segment that is not a namespaced core resource is a subresource of the namespace.  A nil resolver is URIToParts.
+func (r *ResourceResolver) URIToParts(uri string) (string, schema.GroupVersionResource, string, string) {
+	ns, gvr, name, subresource := URIToParts(uri)
+	if r == nil || len(gvr.Group) > 0 || len(ns) == 0 || gvr.Resource == "namespaces" || len(gvr.Resource) == 0 {
+		return ns, gvr, name, subresource
+	}
+	if namespaces, ok := r.Get(schema.GroupResource{Resource: "namespaces"}); !ok || namespaces.Scope != meta.RESTScopeNameRoot {
+		// the core group is not in the discovery, the built-in resources have no scope
+		return ns, gvr, name, subresource
+	}
+	if resource, ok := r.Get(gvr.GroupResource()); ok && resource.Scope != meta.RESTScopeNameRoot {
+		return ns, gvr, name, subresource
+	}
+
+	path := strings.Split(strings.Trim(strings.Split(uri, "?")[0], "/"), "/")
+	return ns, schema.GroupVersionResource{Version: gvr.Version, Resource: "namespaces"}, ns, strings.Join(path[4:], "/")
+}
+
+// ignoredQueryParams are the query parameters that always differ between otherwise identical watches and lists,
+// they are dropped when building the canonical key of a request URI.
+var ignoredQueryParams = []string{"timeout", "timeoutSeconds", "resourceVersion", "continue"}
+
+// auditURIKey normalizes a request URI into a canonical key made of the path and the significant query parameters
+// sorted by name.  Two URIs are equivalent for aggregation purposes when their keys are equal.
+func auditURIKey(uri string) string {
+	parts := strings.SplitN(uri, "?", 2)
+	if len(parts) < 2 || len(parts[1]) == 0 {
+		return parts[0]
+	}
+
+	values, err := url.ParseQuery(parts[1])
+	if err != nil {
+		// we cannot normalize it, so only an exact match is equivalent
+		return uri
+	}
+	for _, param := range ignoredQueryParams {
+		values.Del(param)
+	}
+	if len(values) == 0 {
+		return parts[0]
+	}
+
+	// Encode sorts by parameter name
+	return parts[0] + "?" + values.Encode()
+}
+
+// IsEquivalentAuditURI is fuzzy matcher that allows equivalence on non-exact matches.  This is important for watches and
+// for lists since they can pass a resourceversion and timeout which always diffs, but is rarely importantly different
+func IsEquivalentAuditURI(lhs, rhs string) bool {
+	if lhs == rhs {
+		return true
+	}
+	return auditURIKey(lhs) == auditURIKey(rhs)
+}
@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestURIToParts(t *testing.T) {
+	tests := []struct {
+		uri         string
+		ns          string
+		gvr         schema.GroupVersionResource
+		name        string
+		subresource string
+	}{
+		{
+			uri: "/api/v1/namespaces/foo/pods/bar",
+			ns:  "foo", gvr: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, name: "bar",
+		},
+		{
+			uri: "/api/v1/namespaces/foo/pods/bar/exec?command=sh",
+			ns:  "foo", gvr: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, name: "bar", subresource: "exec",
+		},
+		{
+			uri: "/apis/apps/v1/namespaces/foo/deployments/bar/scale",
+			ns:  "foo", gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, name: "bar", subresource: "scale",
+		},
+		{
+			uri: "/api/v1/nodes",
+			gvr: schema.GroupVersionResource{Version: "v1", Resource: "nodes"},
+		},
+		{
+			uri: "/api/v1/nodes/worker-1",
+			gvr: schema.GroupVersionResource{Version: "v1", Resource: "nodes"}, name: "worker-1",
+		},
+		{
+			uri: "/api/v1/nodes/worker-1/proxy/logs/journal",
+			gvr: schema.GroupVersionResource{Version: "v1", Resource: "nodes"}, name: "worker-1", subresource: "proxy/logs/journal",
+		},
+		{
+			uri: "/apis/config.openshift.io/v1/clusteroperators/etcd/status",
+			gvr: schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "clusteroperators"}, name: "etcd", subresource: "status",
+		},
+		{
+			uri: "/api/v1/namespaces",
+			gvr: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+		},
+		{
+			uri: "/api/v1/namespaces/foo",
+			ns:  "foo", gvr: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, name: "foo",
+		},
+		{
+			uri: "/api/v1/namespaces/foo/finalize",
+			ns:  "foo", gvr: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, name: "foo", subresource: "finalize",
+		},
+		{
+			uri: "/healthz",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.uri, func(t *testing.T) {
+			ns, gvr, name, subresource := URIToParts(test.uri)
+			if ns != test.ns || gvr != test.gvr || name != test.name || subresource != test.subresource {
+				t.Errorf("expected %q %v %q %q, got %q %v %q %q", test.ns, test.gvr, test.name, test.subresource, ns, gvr, name, subresource)
+			}
+		})
+	}
+}
+
+func TestResourceResolverURIToParts(t *testing.T) {
+	resolver := newResourceResolver()
+	if err := resolver.addSnapshotFile(strings.NewReader(`
+{"kind":"APIResourceList","apiVersion":"v1","groupVersion":"v1","resources":[
+  {"name":"namespaces","singularName":"namespace","namespaced":false,"kind":"Namespace","verbs":["get"]},
+  {"name":"pods","singularName":"pod","namespaced":true,"kind":"Pod","verbs":["get"]},
+  {"name":"nodes","singularName":"node","namespaced":false,"kind":"Node","verbs":["get"]}]}
+`)); err != nil {
+		t.Fatal(err)
+	}
+	resolver.addBuiltinResources()
+
+	namespaces := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	tests := []struct {
+		uri         string
+		resolver    *ResourceResolver
+		ns          string
+		gvr         schema.GroupVersionResource
+		name        string
+		subresource string
+	}{
+		{
+			uri: "/api/v1/namespaces/foo/pods/bar/log", resolver: resolver,
+			ns: "foo", gvr: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, name: "bar", subresource: "log",
+		},
+		{
+			// a built-in resource the snapshot does not list keeps its namespace
+			uri: "/api/v1/namespaces/foo/configmaps/bar", resolver: resolver,
+			ns: "foo", gvr: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, name: "bar",
+		},
+		{
+			uri: "/api/v1/namespaces/foo/proxy/healthz", resolver: resolver,
+			ns: "foo", gvr: namespaces, name: "foo", subresource: "proxy/healthz",
+		},
+		{
+			uri: "/api/v1/namespaces/foo/proxy/healthz",
+			ns:  "foo", gvr: schema.GroupVersionResource{Version: "v1", Resource: "proxy"}, name: "healthz",
+		},
+		{
+			uri: "/api/v1/namespaces/foo/finalize", resolver: resolver,
+			ns: "foo", gvr: namespaces, name: "foo", subresource: "finalize",
+		},
+		{
+			uri: "/apis/apps/v1/namespaces/foo/deployments/bar", resolver: resolver,
+			ns: "foo", gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, name: "bar",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.uri, func(t *testing.T) {
+			ns, gvr, name, subresource := test.resolver.URIToParts(test.uri)
+			if ns != test.ns || gvr != test.gvr || name != test.name || subresource != test.subresource {
+				t.Errorf("expected %q %v %q %q, got %q %v %q %q", test.ns, test.gvr, test.name, test.subresource, ns, gvr, name, subresource)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+// watchTimeoutSlack is how much sooner than its timeoutSeconds a watch may end and still count as timed out.
+const watchTimeoutSlack = time.Second
+
+// WatchStats are the watches and lists of one user on one resource.  An informer lists, then watches from the
+// resourceVersion of the list and watches again from its last resourceVersion every time the watch times out.  Watches
+// that end early, watches that start without a resourceVersion and frequent lists point to an informer that keeps
+// losing its place.
+type WatchStats struct {
+	User     string
+	Resource schema.GroupResource
+	Watches  int
+	// Durations are sorted.
+	Durations []time.Duration
+	// ShortLived watches ended sooner than the short lived threshold.
+	ShortLived int
+	// Early watches ended before the timeoutSeconds they asked for.
+	Early int
+	// FromScratch watches had no resourceVersion, the apiserver replays every object to them.
+	FromScratch int
+	Lists       int
+}
+
+// WatchReport holds the watch lifecycles of all the users, Span is the time span of the events the rates are computed
+// over.
+type WatchReport struct {
+	Span  time.Duration
+	Stats []*WatchStats
+}
+
+// BuildWatchReport gathers the completed watches and the lists per user and resource.
+func BuildWatchReport(events []*auditv1.Event, shortLived time.Duration) *WatchReport {
+	report := &WatchReport{}
+	span := EventsTimeSpan(events)
+	report.Span = span.End.Sub(span.Start)
+
+	stats := map[string]*WatchStats{}
+	for _, event := range events {
+		if event.Verb != "watch" && event.Verb != "list" {
+			continue
+		}
+		_, gvr, _, _ := URIToParts(event.RequestURI)
+		key := event.User.Username + "|" + gvr.GroupResource().String()
+		current, ok := stats[key]
+		if !ok {
+			current = &WatchStats{User: event.User.Username, Resource: gvr.GroupResource()}
+			stats[key] = current
+		}
+
+		if event.Verb == "list" {
+			current.Lists++
+			continue
+		}
+
+		params := queryParams(event.RequestURI)
+		duration := Latency(event)
+		current.Watches++
+		current.Durations = append(current.Durations, duration)
+		if duration < shortLived {
+			current.ShortLived++
+		}
+		if timeoutSeconds, err := strconv.ParseInt(params.Get("timeoutSeconds"), 10, 64); err == nil && timeoutSeconds > 0 {
+			if duration+watchTimeoutSlack < time.Duration(timeoutSeconds)*time.Second {
+				current.Early++
+			}
+		}
+		if resourceVersion := params.Get("resourceVersion"); len(resourceVersion) == 0 || resourceVersion == "0" {
+			current.FromScratch++
+		}
+	}
+
+	for _, current := range stats {
+		// lists without watches are the business of -o expensive
+		if current.Watches == 0 {
+			continue
+		}
+		sort.Slice(current.Durations, func(i, j int) bool {
+			return current.Durations[i] < current.Durations[j]
+		})
+		report.Stats = append(report.Stats, current)
+	}
+	sort.Slice(report.Stats, func(i, j int) bool {
+		if report.Stats[i].Watches != report.Stats[j].Watches {
+			return report.Stats[i].Watches > report.Stats[j].Watches
+		}
+		if report.Stats[i].User != report.Stats[j].User {
+			return report.Stats[i].User < report.Stats[j].User
+		}
+		return report.Stats[i].Resource.String() < report.Stats[j].Resource.String()
+	})
+	return report
+}
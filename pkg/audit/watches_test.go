@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestBuildWatchReport(t *testing.T) {
+	start := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		uri         string
+		duration    time.Duration
+		early       bool
+		fromScratch bool
+		shortLived  bool
+	}{
+		{
+			name:     "timed out",
+			uri:      "/api/v1/pods?watch=true&resourceVersion=1234&timeoutSeconds=300",
+			duration: 300 * time.Second,
+		},
+		{
+			name:     "timed out within the slack",
+			uri:      "/api/v1/pods?watch=true&resourceVersion=1234&timeoutSeconds=300",
+			duration: 300*time.Second - watchTimeoutSlack/2,
+		},
+		{
+			name:     "ended early",
+			uri:      "/api/v1/pods?watch=true&resourceVersion=1234&timeoutSeconds=300",
+			duration: 100 * time.Second,
+			early:    true,
+		},
+		{
+			name:       "short lived",
+			uri:        "/api/v1/pods?watch=true&resourceVersion=1234&timeoutSeconds=300",
+			duration:   time.Second,
+			early:      true,
+			shortLived: true,
+		},
+		{
+			name:     "no timeout",
+			uri:      "/api/v1/pods?watch=true&resourceVersion=1234",
+			duration: 100 * time.Second,
+		},
+		{
+			name:        "without a resourceVersion",
+			uri:         "/api/v1/pods?watch=true&timeoutSeconds=300",
+			duration:    300 * time.Second,
+			fromScratch: true,
+		},
+		{
+			name:        "from resourceVersion 0",
+			uri:         "/api/v1/pods?watch=true&resourceVersion=0&timeoutSeconds=300",
+			duration:    300 * time.Second,
+			fromScratch: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events := []*auditv1.Event{
+				{
+					Verb:                     "watch",
+					RequestURI:               test.uri,
+					User:                     authnv1.UserInfo{Username: "system:node:worker-1"},
+					RequestReceivedTimestamp: metav1.NewMicroTime(start),
+					StageTimestamp:           metav1.NewMicroTime(start.Add(test.duration)),
+				},
+				{
+					Verb:                     "list",
+					RequestURI:               "/api/v1/pods?limit=500",
+					User:                     authnv1.UserInfo{Username: "system:node:worker-1"},
+					RequestReceivedTimestamp: metav1.NewMicroTime(start),
+					StageTimestamp:           metav1.NewMicroTime(start),
+				},
+			}
+
+			report := BuildWatchReport(events, 10*time.Second)
+			if len(report.Stats) != 1 {
+				t.Fatalf("expected one user and resource, got %d", len(report.Stats))
+			}
+			stats := report.Stats[0]
+			if stats.Watches != 1 || stats.Lists != 1 {
+				t.Errorf("expected one watch and one list, got %d watches and %d lists", stats.Watches, stats.Lists)
+			}
+			if (stats.Early == 1) != test.early {
+				t.Errorf("expected early %v, got %d", test.early, stats.Early)
+			}
+			if (stats.FromScratch == 1) != test.fromScratch {
+				t.Errorf("expected from scratch %v, got %d", test.fromScratch, stats.FromScratch)
+			}
+			if (stats.ShortLived == 1) != test.shortLived {
+				t.Errorf("expected short lived %v, got %d", test.shortLived, stats.ShortLived)
+			}
+		})
+	}
+}
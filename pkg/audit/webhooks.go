@@ -0,0 +1,220 @@
+package audit
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+const (
+	mutationWebhookAnnotationPrefix = "mutation.webhook.admission.k8s.io/"
+	patchWebhookAnnotationPrefix    = "patch.webhook.admission.k8s.io/"
+	// failed-open.validating.webhook.admission.k8s.io/round_0_index_1 and failed-open.mutation.webhook.admission.k8s.io/...
+	failedOpenWebhookAnnotationPrefix = "failed-open."
+
+	mutatingWebhookLatencyAnnotation   = "apiserver.latency.k8s.io/mutating-webhook"
+	validatingWebhookLatencyAnnotation = "apiserver.latency.k8s.io/validating-webhook"
+
+	// maxWebhookFailureExamples is the number of failed audit IDs kept per webhook.
+	maxWebhookFailureExamples = 5
+)
+
+var (
+	webhookDeniedRegex      = regexp.MustCompile(`admission webhook "([^"]+)" denied the request`)
+	webhookCallFailureRegex = regexp.MustCompile(`failed calling webhook "([^"]+)"`)
+)
+
+// webhookAnnotation is the value of the mutation and patch annotations.
+type webhookAnnotation struct {
+	Configuration string         `json:"configuration"`
+	Webhook       string         `json:"webhook"`
+	Mutated       bool           `json:"mutated"`
+	PatchType     string         `json:"patchType"`
+	Patch         []webhookPatch `json:"patch"`
+}
+
+type webhookPatch struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+}
+
+// WebhookImpact aggregates what an admission webhook did to the requests of the audit log.
+type WebhookImpact struct {
+	Name          string
+	Configuration string
+	// Type is mutating or validating, it is unknown for webhooks only seen in failure messages.
+	Type string
+	// Invocations counts the requests the webhook was seen in.  Validating webhooks are only recorded when they fail.
+	Invocations int
+	Mutations   int
+	Resources   sets.String
+	// Patches counts the patch operations per "<op> <path>".
+	Patches map[string]int
+	// Latencies are the latencies of the whole webhook phase of the requests the webhook was invoked in, the
+	// apiserver does not track the latency per webhook.  They are only recorded for requests slower than 500ms.
+	Latencies        []time.Duration
+	Denials          int
+	CallFailures     int
+	FailedOpen       int
+	FailedAuditIDs   []types.UID
+	failedAuditIDSet sets.String
+}
+
+func newWebhookImpact(name string) *WebhookImpact {
+	return &WebhookImpact{
+		Name:             name,
+		Type:             "unknown",
+		Resources:        sets.NewString(),
+		Patches:          map[string]int{},
+		failedAuditIDSet: sets.NewString(),
+	}
+}
+
+func (w *WebhookImpact) addFailure(auditID types.UID) {
+	if w.failedAuditIDSet.Has(string(auditID)) {
+		return
+	}
+	w.failedAuditIDSet.Insert(string(auditID))
+	if len(w.FailedAuditIDs) < maxWebhookFailureExamples {
+		w.FailedAuditIDs = append(w.FailedAuditIDs, auditID)
+	}
+}
+
+// Failures is the number of requests the webhook rejected, failed or failed open in.
+func (w *WebhookImpact) Failures() int {
+	return w.Denials + w.CallFailures + w.FailedOpen
+}
+
+// BuildWebhookImpactReport reads the admission annotations of the events and the webhook names of the admission
+// failures.  Webhooks are sorted by failures, then by invocations.
+func BuildWebhookImpactReport(events []*auditv1.Event) []*WebhookImpact {
+	webhooks := map[string]*WebhookImpact{}
+	getWebhook := func(name string) *WebhookImpact {
+		if _, ok := webhooks[name]; !ok {
+			webhooks[name] = newWebhookImpact(name)
+		}
+		return webhooks[name]
+	}
+
+	for _, event := range events {
+		_, gvr, _, subresource := URIToParts(event.RequestURI)
+		resource := gvr.Resource
+		if len(gvr.Group) > 0 {
+			resource = gvr.Resource + "." + gvr.Group
+		}
+		if len(subresource) > 0 {
+			resource = resource + "/" + subresource
+		}
+		mutatingLatency, _ := time.ParseDuration(event.Annotations[mutatingWebhookLatencyAnnotation])
+		validatingLatency, _ := time.ParseDuration(event.Annotations[validatingWebhookLatencyAnnotation])
+
+		seen := sets.NewString()
+		invoke := func(name, configuration, webhookType string) *WebhookImpact {
+			webhook := getWebhook(name)
+			if len(configuration) > 0 {
+				webhook.Configuration = configuration
+			}
+			if len(webhookType) > 0 {
+				webhook.Type = webhookType
+			}
+			if seen.Has(name) {
+				return webhook
+			}
+			seen.Insert(name)
+			webhook.Invocations++
+			webhook.Resources.Insert(resource)
+			switch {
+			case webhook.Type == "mutating" && mutatingLatency > 0:
+				webhook.Latencies = append(webhook.Latencies, mutatingLatency)
+			case webhook.Type == "validating" && validatingLatency > 0:
+				webhook.Latencies = append(webhook.Latencies, validatingLatency)
+			}
+			return webhook
+		}
+
+		for key, value := range event.Annotations {
+			switch {
+			case strings.HasPrefix(key, mutationWebhookAnnotationPrefix):
+				annotation := &webhookAnnotation{}
+				if err := json.Unmarshal([]byte(value), annotation); err != nil || len(annotation.Webhook) == 0 {
+					continue
+				}
+				webhook := invoke(annotation.Webhook, annotation.Configuration, "mutating")
+				if annotation.Mutated {
+					webhook.Mutations++
+				}
+
+			case strings.HasPrefix(key, patchWebhookAnnotationPrefix):
+				annotation := &webhookAnnotation{}
+				if err := json.Unmarshal([]byte(value), annotation); err != nil || len(annotation.Webhook) == 0 {
+					continue
+				}
+				webhook := invoke(annotation.Webhook, annotation.Configuration, "mutating")
+				for _, patch := range annotation.Patch {
+					webhook.Patches[patch.Op+" "+patch.Path]++
+				}
+
+			case strings.HasPrefix(key, failedOpenWebhookAnnotationPrefix):
+				// the value is the name of the webhook
+				webhookType := "validating"
+				if strings.HasPrefix(key, failedOpenWebhookAnnotationPrefix+"mutation.") {
+					webhookType = "mutating"
+				}
+				webhook := invoke(value, "", webhookType)
+				webhook.FailedOpen++
+				webhook.addFailure(event.AuditID)
+			}
+		}
+
+		if event.ResponseStatus == nil || len(event.ResponseStatus.Message) == 0 {
+			continue
+		}
+		if matches := webhookDeniedRegex.FindStringSubmatch(event.ResponseStatus.Message); len(matches) > 1 {
+			webhook := invoke(matches[1], "", "")
+			webhook.Denials++
+			webhook.addFailure(event.AuditID)
+		}
+		if matches := webhookCallFailureRegex.FindStringSubmatch(event.ResponseStatus.Message); len(matches) > 1 {
+			webhook := invoke(matches[1], "", "")
+			webhook.CallFailures++
+			webhook.addFailure(event.AuditID)
+		}
+	}
+
+	ret := []*WebhookImpact{}
+	for _, webhook := range webhooks {
+		sort.Slice(webhook.Latencies, func(i, j int) bool {
+			return webhook.Latencies[i] < webhook.Latencies[j]
+		})
+		ret = append(ret, webhook)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Failures() != ret[j].Failures() {
+			return ret[i].Failures() > ret[j].Failures()
+		}
+		if ret[i].Invocations != ret[j].Invocations {
+			return ret[i].Invocations > ret[j].Invocations
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// LatencyPercentile returns the nearest rank percentile of sorted latencies.
+func LatencyPercentile(percentile float64, latencies []time.Duration) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100.0 * float64(len(latencies))))
+	if rank < 1 {
+		rank = 1
+	}
+	return latencies[rank-1]
+}
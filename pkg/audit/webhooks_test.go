@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestBuildWebhookImpactReport(t *testing.T) {
+	events := []*auditv1.Event{
+		{
+			AuditID:    "1",
+			Verb:       "create",
+			RequestURI: "/api/v1/namespaces/foo/pods",
+			Annotations: map[string]string{
+				"mutation.webhook.admission.k8s.io/round_0_index_0": `{"configuration":"a-config","webhook":"a.example.com","mutated":true}`,
+				"patch.webhook.admission.k8s.io/round_0_index_0":    `{"configuration":"a-config","webhook":"a.example.com","patchType":"JSONPatch","patch":[{"op":"add","path":"/metadata/labels"}]}`,
+				"apiserver.latency.k8s.io/mutating-webhook":         "600ms",
+			},
+			ResponseStatus: &metav1.Status{Code: 201},
+		},
+		{
+			AuditID:    "2",
+			Verb:       "create",
+			RequestURI: "/api/v1/namespaces/foo/pods",
+			Annotations: map[string]string{
+				"mutation.webhook.admission.k8s.io/round_0_index_0":               `{"configuration":"a-config","webhook":"a.example.com","mutated":false}`,
+				"failed-open.validating.webhook.admission.k8s.io/round_0_index_0": "v.example.com",
+				"apiserver.latency.k8s.io/validating-webhook":                     "2s",
+			},
+			ResponseStatus: &metav1.Status{Code: 201},
+		},
+		{
+			AuditID:    "3",
+			Verb:       "update",
+			RequestURI: "/apis/apps/v1/namespaces/foo/deployments/bar",
+			ResponseStatus: &metav1.Status{Code: 400,
+				Message: `admission webhook "v.example.com" denied the request: replicas must be odd`},
+		},
+		{
+			AuditID:    "4",
+			Verb:       "update",
+			RequestURI: "/apis/apps/v1/namespaces/foo/deployments/bar/scale",
+			ResponseStatus: &metav1.Status{Code: 500,
+				Message: `Internal error occurred: failed calling webhook "m.example.com": context deadline exceeded`},
+		},
+	}
+
+	webhooks := BuildWebhookImpactReport(events)
+	names := []string{}
+	for _, webhook := range webhooks {
+		names = append(names, webhook.Name)
+	}
+	if expected := []string{"v.example.com", "m.example.com", "a.example.com"}; !reflect.DeepEqual(expected, names) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+
+	validating := webhooks[0]
+	if validating.Type != "validating" || validating.Invocations != 2 || validating.FailedOpen != 1 || validating.Denials != 1 {
+		t.Errorf("expected a validating webhook failing open once and denying once, got %s %d invocations %d failed open %d denials",
+			validating.Type, validating.Invocations, validating.FailedOpen, validating.Denials)
+	}
+	if expected := []string{"deployments.apps", "pods"}; !reflect.DeepEqual(expected, validating.Resources.List()) {
+		t.Errorf("expected %v, got %v", expected, validating.Resources.List())
+	}
+	if !reflect.DeepEqual([]time.Duration{2 * time.Second}, validating.Latencies) {
+		t.Errorf("expected the validating latency, got %v", validating.Latencies)
+	}
+
+	callFailure := webhooks[1]
+	if callFailure.Type != "unknown" || callFailure.CallFailures != 1 || !reflect.DeepEqual(callFailure.Resources.List(), []string{"deployments.apps/scale"}) {
+		t.Errorf("expected a call failure on deployments.apps/scale, got %s %d call failures on %v", callFailure.Type, callFailure.CallFailures, callFailure.Resources.List())
+	}
+
+	mutating := webhooks[2]
+	if mutating.Type != "mutating" || mutating.Configuration != "a-config" || mutating.Invocations != 2 || mutating.Mutations != 1 || mutating.Failures() != 0 {
+		t.Errorf("expected a mutating webhook invoked twice that mutated once, got %s %q %d invocations %d mutations %d failures",
+			mutating.Type, mutating.Configuration, mutating.Invocations, mutating.Mutations, mutating.Failures())
+	}
+	if expected := map[string]int{"add /metadata/labels": 1}; !reflect.DeepEqual(expected, mutating.Patches) {
+		t.Errorf("expected %v, got %v", expected, mutating.Patches)
+	}
+	if !reflect.DeepEqual([]time.Duration{600 * time.Millisecond}, mutating.Latencies) {
+		t.Errorf("expected the mutating latency, got %v", mutating.Latencies)
+	}
+}
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+// The audit log doesn't record the content type of a patch, the patch types are told apart by their query parameters
+// and their body.  A body is only logged at the Request and RequestResponse levels, without it the type of a patch is
+// unknown unless it is a forced apply or an apply conflict.
+const (
+	PatchTypeApply          = "apply"
+	PatchTypeApplyForce     = "apply-force"
+	PatchTypeJSON           = "json"
+	PatchTypeMerge          = "merge"
+	PatchTypeStrategicMerge = "strategic-merge"
+	PatchTypeUnknown        = "unknown"
+)
+
+var PatchTypes = []string{PatchTypeApply, PatchTypeApplyForce, PatchTypeJSON, PatchTypeMerge, PatchTypeStrategicMerge, PatchTypeUnknown}
+
+// the values of FilterBuilder.DryRun
+const (
+	DryRunOnly    = "only"
+	DryRunExclude = "exclude"
+)
+
+// strategicMergeDirectives only appear in strategic merge patches, a strategic merge patch without them is
+// indistinguishable from a merge patch.
+var strategicMergeDirectives = [][]byte{
+	[]byte(`"$patch"`),
+	[]byte(`"$retainKeys"`),
+	[]byte(`"$deleteFromPrimitiveList/`),
+	[]byte(`"$setElementOrder/`),
+}
+
+// IsDryRun is true for the requests with dryRun=All, they go through admission but are never persisted.
+func IsDryRun(event *auditv1.Event) bool {
+	for _, dryRun := range queryParams(event.RequestURI)["dryRun"] {
+		if dryRun == "All" {
+			return true
+		}
+	}
+	return false
+}
+
+// PatchType guesses the type of a patch, it returns "" for the other verbs.
+func PatchType(event *auditv1.Event) string {
+	if event.Verb != "patch" {
+		return ""
+	}
+
+	params := queryParams(event.RequestURI)
+	// force is only allowed for apply patches
+	if params.Get("force") == "true" {
+		return PatchTypeApplyForce
+	}
+	if isApplyConflictStatus(event) {
+		return PatchTypeApply
+	}
+	if event.RequestObject == nil {
+		return PatchTypeUnknown
+	}
+
+	raw := bytes.TrimSpace(event.RequestObject.Raw)
+	if len(raw) == 0 {
+		return PatchTypeUnknown
+	}
+	if raw[0] == '[' {
+		return PatchTypeJSON
+	}
+	body := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return PatchTypeUnknown
+	}
+	// an apply configuration is a full object with its type and requires a field manager
+	_, hasAPIVersion := body["apiVersion"]
+	_, hasKind := body["kind"]
+	if hasAPIVersion && hasKind && len(params.Get("fieldManager")) > 0 {
+		return PatchTypeApply
+	}
+	for _, directive := range strategicMergeDirectives {
+		if bytes.Contains(raw, directive) {
+			return PatchTypeStrategicMerge
+		}
+	}
+	return PatchTypeMerge
+}
+
+func isApplyConflictStatus(event *auditv1.Event) bool {
+	return event.ResponseStatus != nil &&
+		event.ResponseStatus.Code == http.StatusConflict &&
+		strings.HasPrefix(event.ResponseStatus.Message, "Apply failed with")
+}
+
+// IsApplyConflict is true for the server-side apply patches that failed because of conflicts with other field
+// managers.
+func IsApplyConflict(event *auditv1.Event) bool {
+	return event.Verb == "patch" && isApplyConflictStatus(event)
+}
+
+// CountByDryRun counts the writes per verb, the dry-run writes are counted apart from the persisted ones.
+func CountByDryRun(events []*auditv1.Event) []NamedCount {
+	counts := map[string]int{}
+	for _, event := range events {
+		if !isWriteVerb(event.Verb) {
+			continue
+		}
+		if IsDryRun(event) {
+			counts[event.Verb+" (dry-run)"]++
+			continue
+		}
+		counts[event.Verb]++
+	}
+	return sortNamedCounts(counts)
+}
+
+// CountByPatchType counts the patches per patch type, the apply conflicts are counted apart.
+func CountByPatchType(events []*auditv1.Event) []NamedCount {
+	counts := map[string]int{}
+	for _, event := range events {
+		patchType := PatchType(event)
+		if len(patchType) == 0 {
+			continue
+		}
+		if IsApplyConflict(event) {
+			patchType += " (conflict)"
+		}
+		counts[patchType]++
+	}
+	return sortNamedCounts(counts)
+}
@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestPatchType(t *testing.T) {
+	tests := []struct {
+		name     string
+		verb     string
+		uri      string
+		body     string
+		status   *metav1.Status
+		expected string
+	}{
+		{
+			name:     "not a patch",
+			verb:     "update",
+			uri:      "/api/v1/namespaces/foo/configmaps/bar",
+			body:     `{"kind":"ConfigMap","apiVersion":"v1"}`,
+			expected: "",
+		},
+		{
+			name:     "apply",
+			verb:     "patch",
+			uri:      "/api/v1/namespaces/foo/configmaps/bar?fieldManager=operator",
+			body:     `{"kind":"ConfigMap","apiVersion":"v1","data":{"a":"b"}}`,
+			expected: PatchTypeApply,
+		},
+		{
+			name:     "forced apply without a body",
+			verb:     "patch",
+			uri:      "/api/v1/namespaces/foo/configmaps/bar?fieldManager=operator&force=true",
+			expected: PatchTypeApplyForce,
+		},
+		{
+			name:     "apply conflict without a body",
+			verb:     "patch",
+			uri:      "/api/v1/namespaces/foo/configmaps/bar?fieldManager=operator",
+			status:   &metav1.Status{Code: 409, Message: `Apply failed with 1 conflict: conflict with "kubectl": .data.a`},
+			expected: PatchTypeApply,
+		},
+		{
+			name:     "json",
+			verb:     "patch",
+			uri:      "/api/v1/namespaces/foo/configmaps/bar",
+			body:     `[{"op":"replace","path":"/data/a","value":"b"}]`,
+			expected: PatchTypeJSON,
+		},
+		{
+			name:     "strategic merge",
+			verb:     "patch",
+			uri:      "/api/v1/namespaces/foo/pods/bar",
+			body:     `{"metadata":{"$setElementOrder/finalizers":["a"]}}`,
+			expected: PatchTypeStrategicMerge,
+		},
+		{
+			name:     "merge",
+			verb:     "patch",
+			uri:      "/api/v1/namespaces/foo/configmaps/bar?fieldManager=kubectl-edit",
+			body:     `{"data":{"a":"b"}}`,
+			expected: PatchTypeMerge,
+		},
+		{
+			name:     "no body",
+			verb:     "patch",
+			uri:      "/api/v1/namespaces/foo/configmaps/bar",
+			expected: PatchTypeUnknown,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			event := &auditv1.Event{Verb: tc.verb, RequestURI: tc.uri, ResponseStatus: tc.status}
+			if len(tc.body) > 0 {
+				event.RequestObject = &runtime.Unknown{Raw: []byte(tc.body)}
+			}
+			if actual := PatchType(event); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsDryRun(t *testing.T) {
+	if !IsDryRun(&auditv1.Event{RequestURI: "/api/v1/namespaces/foo?dryRun=All&fieldManager=psa-check"}) {
+		t.Errorf("expected dryRun=All to be a dry run")
+	}
+	if IsDryRun(&auditv1.Event{RequestURI: "/api/v1/namespaces/foo"}) {
+		t.Errorf("expected a request without dryRun not to be a dry run")
+	}
+}
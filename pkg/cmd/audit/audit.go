@@ -1,19 +1,22 @@
 package audit
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/sets"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
 
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
 	"github.com/openshift/cluster-debug-tools/pkg/util"
 )
 
@@ -31,8 +34,61 @@ var (
 	# find CREATEs of everything except SAR and tokenreview
 	%[1]s audit -f audit.log --verb=create --resource=*.* --resource=-subjectaccessreviews.* --resource=-tokenreviews.*
 
+	# find the writes to machines and deployments by kind, resolved with the CRDs of a must-gather
+	%[1]s audit -f audit.log --discovery=must-gather/cluster-scoped-resources/apiextensions.k8s.io --kind=Machine,Deployment --verb=create,update,patch,delete
+
+	# count the requests to the resources of the "all" category per kind, with the discovery of the current kubeconfig
+	%[1]s audit -f audit.log --discovery=cluster --resource=all --output=top --by=kind
+
+	# watch the failed requests of a namespace live on a master, eg. from oc debug node/<master>
+	%[1]s audit -f /host/var/log/kube-apiserver/audit.log --follow --namespace=openshift-etcd --failed-only
+
 	# filter event by stages
 	%[1]s audit -f audit.log --verb=get --stage=ResponseComplete --output=top --by=verb
+
+	# show the failed requests five minutes around 10:12 UTC, or in the last ten minutes of the log
+	%[1]s audit -f audit.log --failed-only --around=10:12 --window=5m
+	%[1]s audit -f audit.log --failed-only --after=end-10m
+
+	# show leader transitions and node heartbeat gaps longer than a minute
+	%[1]s audit -f audit.log --output=leases --lease-gap=1m
+
+	# export who read secrets or exec'ed into pods as CSV for a security review
+	%[1]s audit -f audit.log --output=sensitive-csv > sensitive.csv
+
+	# rank the moments a client started hammering the API, per user agent
+	%[1]s audit -f audit.log --output=rates=20 --by=useragent --rate-window=30s
+
+	# aggregate the pod security violations of a CI run like psa-check does on a live cluster
+	%[1]s audit -f audit.log --podsecurityviolations=all --output=psa-report
+
+	# find the admission webhooks that slow down or fail requests
+	%[1]s audit -f audit.log --output=webhooks
+
+	# collapse the failed requests into their distinct problems
+	%[1]s audit -f audit.log --failed-only --output=failures=5
+
+	# find the create/delete storms and the objects a controller keeps recreating
+	%[1]s audit -f audit.log --verb=create,delete --output=churn=20 --short-lived=30s
+
+	# rank the users by the cost of their expensive lists to know which operator to file a bug against
+	%[1]s audit -f audit.log --output=expensive
+
+	# find the largest objects written and the largest list responses of an audit log captured at RequestResponse level
+	%[1]s audit -f audit.log --output=sizes=20
+
+	# count the writes without the dry-run updates psa-check issues, then the server-side apply conflicts per user
+	%[1]s audit -f audit.log --dry-run=exclude --verb=create,update,patch,delete --output=top --by=resource
+	%[1]s audit -f audit.log --apply-conflicts --output=top --by=user
+
+	# split the patches by patch type: apply, apply-force, json, merge, strategic-merge
+	%[1]s audit -f audit.log --verb=patch --output=top --by=patchtype
+
+	# run the saved query gc-deployments of ~/.config/kubectl-dev_tool/queries.yaml or of the project, see query list
+	%[1]s audit -f audit.log --query=gc-deployments
+
+	# find the informers that keep re-watching or re-listing, eg. because of broken bookmark handling
+	%[1]s audit -f audit.log --verb=list,watch --output=watches=20
 `
 )
 
@@ -41,36 +97,28 @@ type AuditOptions struct {
 	builder    *resource.Builder
 	args       []string
 
-	verbs             []string
-	resources         []string
-	subresources      []string
-	namespaces        []string
-	names             []string
-	users             []string
-	fieldManagers     []string
-	uids              []string
-	filenames         []string
-	failedOnly        bool
-	httpStatusCodes   []int32
-	output            string
-	topBy             string
-	beforeString      string
-	afterString       string
-	stages            []string
-	duration          string
-	podsecurityfilter string
+	filterOptions *AuditFilterOptions
+	filenames     []string
+	output        string
+	topBy         string
+	leaseGap      time.Duration
+	rateOptions   audit.RateOptions
+	follow        bool
+	shortLived    time.Duration
+	query         string
 
 	genericclioptions.IOStreams
 }
 
 func NewAuditOptions(streams genericclioptions.IOStreams) *AuditOptions {
 	return &AuditOptions{
-		IOStreams: streams,
-		stages: []string{
-			// We are making RequestReceived the default stage,
-			// this will provide a protection against double counting of events.
-			"ResponseComplete",
+		filterOptions: NewAuditFilterOptions(),
+		rateOptions: audit.RateOptions{
+			Window:       time.Minute,
+			BurstFactor:  5,
+			SustainedQPS: 5,
 		},
+		IOStreams: streams,
 	}
 }
 
@@ -99,31 +147,43 @@ func NewCmdAudit(parentName string, streams genericclioptions.IOStreams) *cobra.
 
 	cmd.Flags().StringSliceVarP(&o.filenames, "filename", "f", o.filenames, "Search for audit logs that contains specified URI")
 	cmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Choose your output format")
-	cmd.Flags().StringSliceVar(&o.uids, "uid", o.uids, "Only match specific UIDs")
-	cmd.Flags().StringSliceVar(&o.verbs, "verb", o.verbs, "Filter result of search to only contain the specified verb (eg. 'update', 'get', etc..)")
-	cmd.Flags().StringSliceVar(&o.resources, "resource", o.resources, "Filter result of search to only contain the specified resource.)")
-	cmd.Flags().StringSliceVar(&o.subresources, "subresource", o.subresources, "Filter result of search to only contain the specified subresources.  \"-*\" means no subresource)")
-	cmd.Flags().StringSliceVarP(&o.namespaces, "namespace", "n", o.namespaces, "Filter result of search to only contain the specified namespace.")
-	cmd.Flags().StringSliceVar(&o.names, "name", o.names, "Filter result of search to only contain the specified name.)")
-	cmd.Flags().StringSliceVar(&o.users, "user", o.users, "Filter result of search to only contain the specified user.)")
-	cmd.Flags().StringSliceVar(&o.fieldManagers, "field-manager", o.fieldManagers, "Filter result of search to only contain the specified fieldManager.)")
-	cmd.Flags().StringVar(&o.topBy, "by", o.topBy, "Switch the top output format (eg. -o top -by [verb,user,resource,httpstatus,namespace]).")
-	cmd.Flags().BoolVar(&o.failedOnly, "failed-only", false, "Filter result of search to only contain http failures.)")
-	cmd.Flags().Int32SliceVar(&o.httpStatusCodes, "http-status-code", o.httpStatusCodes, "Filter result of search to only certain http status codes (200,429).")
-	cmd.Flags().StringVar(&o.beforeString, "before", o.beforeString, "Filter result of search to only before a timestamp.)")
-	cmd.Flags().StringVar(&o.afterString, "after", o.afterString, "Filter result of search to only after a timestamp.)")
-	cmd.Flags().StringSliceVarP(&o.stages, "stage", "s", o.stages, "Filter result by event stage (eg. 'RequestReceived', 'ResponseComplete'), if omitted all stages will be included)")
-	cmd.Flags().StringVar(&o.duration, "duration", o.duration, "Filter all requests that didn't take longer than the specified timeout to complete. Keep in mind that requests usually don't take exactly the specified time. Adding a second or two should give you what you want.")
-	cmd.Flags().StringVar(&o.podsecurityfilter, "podsecurityviolations", "", "Filter pod security admission violations. Possible values: 'pod', 'all'; for either pod violations only, or violations of both pods and pod controllers")
+	cmd.Flags().BoolVar(&o.follow, "follow", o.follow, "Print the matching events as they are appended to the audit log, following the rotations of the log like tail -F. Only the default, wide and json outputs are supported.")
+	cmd.Flags().StringVar(&o.topBy, "by", o.topBy, "Switch the top output format (eg. -o top -by [verb,user,resource,httpstatus,namespace,kind,dryrun,patchtype], -o rates -by [user,useragent]).")
+	o.filterOptions.AddFlags(cmd.Flags())
+	util.AddQueryFlag(cmd.Flags(), &o.query)
+	cmd.Flags().DurationVar(&o.shortLived, "short-lived", 10*time.Second, "Count the objects deleted sooner than this duration after their creation and the watches ending sooner than this duration as short lived (eg. -o churn --short-lived=30s).")
+	cmd.Flags().DurationVar(&o.leaseGap, "lease-gap", 40*time.Second, "Flag lease renewals that are further apart than this duration (eg. -o leases --lease-gap=1m).")
+
+	cmd.Flags().DurationVar(&o.rateOptions.Window, "rate-window", o.rateOptions.Window, "The sliding window the request rates are computed over (eg. -o rates --rate-window=30s).")
+	cmd.Flags().Float64Var(&o.rateOptions.BurstFactor, "burst-factor", o.rateOptions.BurstFactor, "Flag windows where a client is this many times faster than its own baseline, the higher of its median and mean rates.")
+	cmd.Flags().Float64Var(&o.rateOptions.SustainedQPS, "sustained-qps", o.rateOptions.SustainedQPS, "Flag windows where a client is faster than this rate, client-go defaults to 5 qps.")
+
+	cmd.AddCommand(NewCmdNamespaceDeletion(parentName, streams))
+	cmd.AddCommand(NewCmdExtract(parentName, streams))
+	cmd.AddCommand(NewCmdPolicySimulate(parentName, streams))
 
 	return cmd
 }
 
 func (o *AuditOptions) Complete(command *cobra.Command, args []string) error {
-	return nil
+	return util.ApplyQuery(command, o.query)
 }
 
 func (o *AuditOptions) Validate() error {
+	if o.follow {
+		if len(o.filenames) != 1 {
+			return fmt.Errorf("--follow requires exactly one audit log")
+		}
+		if info, err := os.Stat(o.filenames[0]); err != nil {
+			return err
+		} else if info.IsDir() {
+			return fmt.Errorf("--follow requires an audit log, not a directory")
+		}
+		if o.output != "" && o.output != "wide" && o.output != "json" {
+			return fmt.Errorf("--follow supports only the default, wide and json outputs")
+		}
+	}
+
 	switch {
 	case o.output == "":
 	case strings.HasPrefix(o.output, "top"):
@@ -134,23 +194,51 @@ func (o *AuditOptions) Validate() error {
 		if err := validateTopBy(o.topBy); err != nil {
 			return err
 		}
-		if err := validatePodSecurityFilter(o.podsecurityfilter); err != nil {
-			return err
+		if o.topBy == "kind" && len(o.filterOptions.discovery) == 0 {
+			return fmt.Errorf("-by kind requires --discovery")
 		}
 	case o.output == "wide":
 	case o.output == "json":
 	case o.output == "stats":
-	default:
-		return fmt.Errorf("unsupported output format: top=N, wide, json")
-	}
-
-	if len(o.duration) > 0 {
-		if _, err := time.ParseDuration(o.duration); err != nil {
-			return fmt.Errorf("incorrect duration specified, err %v", err)
+	case o.output == "leases":
+	case o.output == "sensitive", o.output == "sensitive-csv":
+	case o.output == "psa-report":
+	case o.output == "webhooks":
+	case strings.HasPrefix(o.output, "churn"):
+		if _, err := namedN("churn", o.output); err != nil {
+			return err
+		}
+	case strings.HasPrefix(o.output, "expensive"):
+		if _, err := namedN("expensive", o.output); err != nil {
+			return err
 		}
+	case strings.HasPrefix(o.output, "watches"):
+		if _, err := namedN("watches", o.output); err != nil {
+			return err
+		}
+	case strings.HasPrefix(o.output, "failures"):
+		if _, err := namedN("failures", o.output); err != nil {
+			return err
+		}
+	case strings.HasPrefix(o.output, "sizes"):
+		if _, err := namedN("sizes", o.output); err != nil {
+			return err
+		}
+	case strings.HasPrefix(o.output, "rates"):
+		if _, err := namedN("rates", o.output); err != nil {
+			return err
+		}
+		if o.topBy != "" && o.topBy != "user" && o.topBy != "useragent" {
+			return fmt.Errorf("unsupported -by value for rates: [user,useragent]")
+		}
+		if o.rateOptions.Window < time.Second {
+			return fmt.Errorf("--rate-window must be at least a second")
+		}
+	default:
+		return fmt.Errorf("unsupported output format: top=N, wide, json, stats, leases, sensitive, sensitive-csv, rates=N, psa-report, webhooks, failures=N, churn=N, expensive=N, watches=N, sizes=N")
 	}
 
-	return nil
+	return o.filterOptions.Validate()
 }
 
 func validateTopBy(topBy string) error {
@@ -160,33 +248,44 @@ func validateTopBy(topBy string) error {
 	case "resource":
 	case "httpstatus":
 	case "namespace":
+	case "kind":
+	case "dryrun":
+	case "patchtype":
 	default:
-		return fmt.Errorf("unsupported -by value: [verb,user,resource,httpstatus,namespace]")
+		return fmt.Errorf("unsupported -by value: [verb,user,resource,httpstatus,namespace,kind,dryrun,patchtype]")
 	}
 	return nil
 }
 
 func topN(output string) (int, error) {
-	if output == "top" {
+	return namedN("top", output)
+}
+
+// namedN parses output formats that display the first N results, eg. top=N.
+func namedN(name, output string) (int, error) {
+	if output == name {
 		return 10, nil
 	}
-	if !strings.HasPrefix(output, "top=") {
-		return 10, fmt.Errorf("%q is not top=N", output)
+	if !strings.HasPrefix(output, name+"=") {
+		return 10, fmt.Errorf("%q is not %s=N", output, name)
 	}
 
-	nString := output[len("top="):]
+	nString := output[len(name+"="):]
 	n, err := strconv.ParseInt(nString, 10, 32)
 	if err != nil {
 		return 10, err
 	}
+	if n < 1 {
+		return 10, fmt.Errorf("%q must display at least one result", output)
+	}
 	return int(n), nil
 }
 
 func validatePodSecurityFilter(podsecurityfilter string) error {
 	switch podsecurityfilter {
 	case "":
-	case PodSecurityViolationsAll:
-	case PodSecurityViolationsPod:
+	case audit.PodSecurityViolationsAll:
+	case audit.PodSecurityViolationsPod:
 	default:
 		return fmt.Errorf("unsupported -podsecurityviolations value: %q; available values are: [pod,all]", podsecurityfilter)
 	}
@@ -194,78 +293,21 @@ func validatePodSecurityFilter(podsecurityfilter string) error {
 }
 
 func (o *AuditOptions) Run() error {
-	filters := AuditFilters{}
-	if len(o.uids) > 0 {
-		filters = append(filters, &FilterByUIDs{UIDs: sets.NewString(o.uids...)})
-	}
-	if len(o.names) > 0 {
-		filters = append(filters, &FilterByNames{Names: sets.NewString(o.names...)})
-	}
-	if len(o.namespaces) > 0 {
-		filters = append(filters, &FilterByNamespaces{Namespaces: sets.NewString(o.namespaces...)})
-	}
-	if len(o.stages) > 0 {
-		filters = append(filters, &FilterByStage{Stages: sets.NewString(o.stages...)})
-	}
-	if len(o.beforeString) > 0 {
-		t, err := time.Parse(time.RFC3339, o.beforeString)
+	if o.follow {
+		// there are no events yet, relative times are relative to now
+		now := time.Now()
+		filters, err := o.filterOptions.ToFilters(util.TimeSpan{Start: now, End: now})
 		if err != nil {
 			return err
 		}
-		filters = append(filters, &FilterByBefore{Before: t})
+		return o.runFollow(filters)
 	}
-	if len(o.afterString) > 0 {
-		t, err := time.Parse(time.RFC3339, o.afterString)
-		if err != nil {
-			return err
-		}
-		filters = append(filters, &FilterByAfter{After: t})
-	}
-	if len(o.resources) > 0 {
-		resources := map[schema.GroupResource]bool{}
-		for _, resource := range o.resources {
-			parts := strings.Split(resource, ".")
-			gr := schema.GroupResource{}
-			gr.Resource = parts[0]
-			if len(parts) >= 2 {
-				gr.Group = strings.Join(parts[1:], ".")
-			}
-			resources[gr] = true
-		}
 
-		filters = append(filters, &FilterByResources{Resources: resources})
-	}
-	if len(o.subresources) > 0 {
-		filters = append(filters, &FilterBySubresources{Subresources: sets.NewString(o.subresources...)})
-	}
-	if len(o.users) > 0 {
-		filters = append(filters, &FilterByUser{Users: sets.NewString(o.users...)})
-	}
-	if len(o.fieldManagers) > 0 {
-		filters = append(filters, &FilterByFieldManager{FieldManagers: sets.NewString(o.fieldManagers...)})
-	}
-	if len(o.verbs) > 0 {
-		filters = append(filters, &FilterByVerbs{Verbs: sets.NewString(o.verbs...)})
-	}
-	if len(o.httpStatusCodes) > 0 {
-		filters = append(filters, &FilterByHTTPStatus{HTTPStatusCodes: sets.NewInt32(o.httpStatusCodes...)})
-	}
-	if o.failedOnly {
-		filters = append(filters, &FilterByFailures{})
-	}
-	if len(o.duration) > 0 {
-		d, err := time.ParseDuration(o.duration)
-		if err != nil {
-			return err
-		}
-		filters = append(filters, &FilterByDuration{d})
-	}
-
-	if psaFilterType := o.podsecurityfilter; len(psaFilterType) > 0 {
-		filters = append(filters, NewFilterByPodSecurityViolations(psaFilterType))
+	events, err := GetEvents(o.ErrOut, o.filenames...)
+	if err != nil {
+		return err
 	}
-
-	events, err := GetEvents(o.filenames...)
+	filters, err := o.filterOptions.ToFilters(audit.EventsTimeSpan(events))
 	if err != nil {
 		return err
 	}
@@ -283,13 +325,23 @@ func (o *AuditOptions) Run() error {
 		case "verb":
 			PrintTopByVerbAuditEvents(o.Out, numToDisplay, events)
 		case "user":
-			PrintTopByUserAuditEvents(o.Out, numToDisplay, events)
+			PrintNamedCounts(o.Out, numToDisplay, audit.CountByUser(events))
 		case "resource":
-			PrintTopByResourceAuditEvents(o.Out, numToDisplay, events)
+			PrintNamedCounts(o.Out, numToDisplay, audit.CountByResource(events))
 		case "httpstatus":
 			PrintTopByHTTPStatusCodeAuditEvents(o.Out, numToDisplay, events)
 		case "namespace":
-			PrintTopByNamespace(o.Out, numToDisplay, events)
+			PrintNamedCounts(o.Out, numToDisplay, audit.CountByNamespace(events))
+		case "kind":
+			resolver, err := o.filterOptions.ToResolver()
+			if err != nil {
+				return err
+			}
+			PrintNamedCounts(o.Out, numToDisplay, audit.CountByKind(events, resolver))
+		case "dryrun":
+			PrintNamedCounts(o.Out, numToDisplay, audit.CountByDryRun(events))
+		case "patchtype":
+			PrintNamedCounts(o.Out, numToDisplay, audit.CountByPatchType(events))
 		default:
 			return fmt.Errorf("unsupported -by value")
 		}
@@ -304,9 +356,77 @@ func (o *AuditOptions) Run() error {
 		}
 	case o.output == "stats":
 		PrintLatencyTrackersStatsAuditEvents(o.Out, events)
+	case o.output == "leases":
+		PrintLeaseTimelines(o.Out, audit.BuildLeaseTimelines(events, o.leaseGap), o.leaseGap)
+	case o.output == "sensitive":
+		PrintSensitiveAccessReport(o.Out, audit.BuildSensitiveAccessReport(events))
+	case o.output == "sensitive-csv":
+		return PrintSensitiveAccessReportCSV(o.Out, audit.BuildSensitiveAccessReport(events))
+	case strings.HasPrefix(o.output, "rates"):
+		numToDisplay, err := namedN("rates", o.output)
+		if err != nil {
+			return err
+		}
+		rateOptions := o.rateOptions
+		rateOptions.By = o.topBy
+		PrintRateAnomalies(o.Out, numToDisplay, audit.FindRateAnomalies(events, rateOptions))
+	case strings.HasPrefix(o.output, "expensive"):
+		numToDisplay, err := namedN("expensive", o.output)
+		if err != nil {
+			return err
+		}
+		PrintExpensiveRequests(o.Out, numToDisplay, audit.FindExpensiveRequests(events))
+	case strings.HasPrefix(o.output, "watches"):
+		numToDisplay, err := namedN("watches", o.output)
+		if err != nil {
+			return err
+		}
+		PrintWatchReport(o.Out, numToDisplay, audit.BuildWatchReport(events, o.shortLived), o.shortLived)
+	case strings.HasPrefix(o.output, "churn"):
+		numToDisplay, err := namedN("churn", o.output)
+		if err != nil {
+			return err
+		}
+		churns, recreated := audit.BuildObjectChurn(events, o.shortLived)
+		PrintObjectChurn(o.Out, numToDisplay, churns, recreated, o.shortLived)
+	case strings.HasPrefix(o.output, "failures"):
+		numToDisplay, err := namedN("failures", o.output)
+		if err != nil {
+			return err
+		}
+		PrintFailureClusters(o.Out, numToDisplay, audit.ClusterFailures(events))
+	case strings.HasPrefix(o.output, "sizes"):
+		numToDisplay, err := namedN("sizes", o.output)
+		if err != nil {
+			return err
+		}
+		PrintSizeReport(o.Out, numToDisplay, audit.BuildSizeReport(events, numToDisplay))
+	case o.output == "webhooks":
+		PrintWebhookImpactReport(o.Out, audit.BuildWebhookImpactReport(events))
+	case o.output == "psa-report":
+		return PrintPodSecurityViolations(o.Out, BuildPodSecurityViolations(events))
 	default:
 		return fmt.Errorf("unsupported output format")
 	}
 
 	return nil
 }
+
+// runFollow prints the events as they are appended to the audit log until interrupted.
+func (o *AuditOptions) runFollow(filters audit.Filters) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	encoder := json.NewEncoder(o.Out)
+	return audit.FollowEvents(ctx, o.filenames[0], filters, func(event *auditv1.Event) error {
+		switch o.output {
+		case "wide":
+			PrintAuditEventsWide(o.Out, []*auditv1.Event{event})
+		case "json":
+			return encoder.Encode(event)
+		default:
+			PrintAuditEvents(o.Out, []*auditv1.Event{event})
+		}
+		return nil
+	})
+}
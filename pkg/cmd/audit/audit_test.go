@@ -0,0 +1,31 @@
+package audit
+
+import (
+	"testing"
+)
+
+func TestNamedN(t *testing.T) {
+	tests := []struct {
+		output      string
+		expected    int
+		expectedErr bool
+	}{
+		{output: "sizes", expected: 10},
+		{output: "sizes=3", expected: 3},
+		{output: "sizes=0", expectedErr: true},
+		{output: "sizes=-1", expectedErr: true},
+		{output: "sizes=foo", expectedErr: true},
+		{output: "top=3", expectedErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.output, func(t *testing.T) {
+			actual, err := namedN("sizes", test.output)
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+			if err == nil && actual != test.expected {
+				t.Errorf("expected %d, got %d", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+)
+
+func PrintObjectChurn(writer io.Writer, numToDisplay int, churns []*audit.ObjectChurn, recreated []*audit.RecreatedObject, shortLived time.Duration) {
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	if len(churns) > numToDisplay {
+		churns = churns[:numToDisplay]
+	}
+	fmt.Fprintf(w, "  RESOURCE\tNAMESPACE\tCREATES\tDELETES\tSHORT LIVED (<%v)\tMIN LIFESPAN\tMEDIAN LIFESPAN\tCREATED BY\tDELETED BY\n", shortLived)
+	for _, churn := range churns {
+		marker := " "
+		if churn.ShortLived > 0 {
+			marker = "!"
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\n",
+			marker,
+			churn.Resource.String(),
+			churn.Namespace,
+			churn.Creates,
+			churn.Deletes,
+			churn.ShortLived,
+			formatLifespan(churn.Lifespans, 0),
+			formatLifespan(churn.Lifespans, 50),
+			strings.Join(truncateList(churn.Creators.List(), 3), ","),
+			strings.Join(truncateList(churn.Deleters.List(), 3), ","))
+	}
+	w.Flush()
+
+	if len(recreated) == 0 {
+		return
+	}
+	if len(recreated) > numToDisplay {
+		recreated = recreated[:numToDisplay]
+	}
+	fmt.Fprintf(writer, "\nRecreated %d or more times:\n", audit.MinRecreations)
+	w = tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "CREATES\tRESOURCE\tNAMESPACE/NAME\tMEDIAN LIFESPAN\tCREATED BY\tDELETED BY\n")
+	for _, object := range recreated {
+		creators, deleters := sets.NewString(), sets.NewString()
+		lifespans := []time.Duration{}
+		creates := 0
+		for _, lifecycle := range object.Lifecycles {
+			if !lifecycle.Created.IsZero() {
+				creates++
+				creators.Insert(lifecycle.Creator)
+			}
+			if !lifecycle.Deleted.IsZero() {
+				deleters.Insert(lifecycle.Deleter)
+			}
+			if lifespan, ok := lifecycle.Lifespan(); ok {
+				lifespans = append(lifespans, lifespan)
+			}
+		}
+		sort.Slice(lifespans, func(i, j int) bool {
+			return lifespans[i] < lifespans[j]
+		})
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			creates,
+			object.Resource.String(),
+			strings.TrimPrefix(object.Namespace+"/"+object.Name, "/"),
+			formatLifespan(lifespans, 50),
+			strings.Join(creators.List(), ","),
+			strings.Join(deleters.List(), ","))
+	}
+	w.Flush()
+}
+
+// formatLifespan prints a percentile of sorted lifespans, or - when none is known.
+func formatLifespan(lifespans []time.Duration, percentile float64) string {
+	if len(lifespans) == 0 {
+		return "-"
+	}
+	return audit.LatencyPercentile(percentile, lifespans).String()
+}
@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+)
+
+func PrintExpensiveRequests(writer io.Writer, numToDisplay int, users []*audit.ExpensiveRequests) {
+	if len(users) > numToDisplay {
+		users = users[:numToDisplay]
+	}
+
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TOTAL LATENCY\tLISTS\tAVG LATENCY\t%s\tUSER\n", strings.ToUpper(strings.Join(audit.ExpensiveReasons, "\t")))
+	for _, user := range users {
+		reasonCounts := []string{}
+		for _, reason := range audit.ExpensiveReasons {
+			reasonCounts = append(reasonCounts, fmt.Sprintf("%d", user.ReasonToCount[reason]))
+		}
+		fmt.Fprintf(w, "%v\t%d\t%v\t%s\t%s\n",
+			user.TotalLatency.Round(time.Millisecond),
+			user.Count,
+			(user.TotalLatency / time.Duration(user.Count)).Round(time.Millisecond),
+			strings.Join(reasonCounts, "\t"),
+			user.User)
+	}
+	w.Flush()
+
+	for _, user := range users {
+		fmt.Fprintf(writer, "\n%s:\n", user.User)
+		PrintAuditEventsWide(writer, user.Examples)
+	}
+}
@@ -0,0 +1,221 @@
+package audit
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+	"github.com/openshift/cluster-debug-tools/pkg/util"
+)
+
+var (
+	extractExample = `
+	# slice the failed calls to the e2e-test-foo namespace out of a must-gather
+	%[1]s audit extract -f must-gather/audit_logs/kube-apiserver --namespace=e2e-test-foo --failed-only --out=sliced.log.gz
+
+	# the same, with users, IPs, namespaces and names hashed so it can be attached to a public bug
+	%[1]s audit extract -f must-gather/audit_logs/kube-apiserver --namespace=e2e-test-foo --failed-only --out=sliced.log.gz --redact
+
+	# the slice loads back into the audit command
+	%[1]s audit -f sliced.log.gz -o top --by=verb
+`
+)
+
+type ExtractOptions struct {
+	filterOptions *AuditFilterOptions
+	filenames     []string
+	out           string
+	redact        bool
+	redactSalt    string
+
+	genericclioptions.IOStreams
+}
+
+func NewExtractOptions(streams genericclioptions.IOStreams) *ExtractOptions {
+	return &ExtractOptions{
+		filterOptions: NewAuditFilterOptions(),
+		IOStreams:     streams,
+	}
+}
+
+func NewCmdExtract(parentName string, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewExtractOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:          "extract -f=audit.file --out=sliced.log.gz [flags]",
+		Short:        "Writes the matching audit events back out in the audit log format, optionally redacted.",
+		Example:      fmt.Sprintf(extractExample, parentName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.Complete(c, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			if err := o.Run(); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&o.filenames, "filename", "f", o.filenames, "Search for audit logs that contains specified URI")
+	cmd.Flags().StringVar(&o.out, "out", o.out, "The file to write the matching events to, gzipped when it ends with .gz. Defaults to stdout.")
+	cmd.Flags().BoolVar(&o.redact, "redact", o.redact, "Hash usernames, user agents, IPs, namespaces and object names, and strip the request and response bodies, the response status details and the annotations other than latencies and decisions. Only the IPs and the quoted names, namespaces and users of response status messages are hashed, the rest of the message is kept.")
+	cmd.Flags().StringVar(&o.redactSalt, "redact-salt", o.redactSalt, "The salt of the redaction hashes, set it to get the same hashes across runs. Defaults to a random salt.")
+	o.filterOptions.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *ExtractOptions) Complete(command *cobra.Command, args []string) error {
+	if o.redact && len(o.redactSalt) == 0 {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		o.redactSalt = hex.EncodeToString(salt)
+	}
+	return nil
+}
+
+func (o *ExtractOptions) Validate() error {
+	if len(o.filenames) == 0 {
+		return fmt.Errorf("at least one audit log must be specified with -f")
+	}
+	return o.filterOptions.Validate()
+}
+
+func (o *ExtractOptions) Run() (err error) {
+	// the logs are streamed, they are only read twice when the time window is relative to them
+	span := util.TimeSpan{}
+	if o.filterOptions.NeedsTimeSpan() {
+		if span, err = o.timeSpan(); err != nil {
+			return err
+		}
+	}
+	filters, err := o.filterOptions.ToFilters(span)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = o.Out
+	if len(o.out) > 0 && o.out != "-" {
+		file, err := os.Create(o.out)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		out = file
+
+		if strings.HasSuffix(o.out, ".gz") {
+			zw := gzip.NewWriter(file)
+			defer func() {
+				if closeErr := zw.Close(); err == nil {
+					err = closeErr
+				}
+			}()
+			out = zw
+		}
+	}
+
+	var redactor *auditRedactor
+	if o.redact {
+		redactor = newAuditRedactor(o.redactSalt)
+	}
+
+	total, matched := 0, 0
+	err = o.walkAuditFiles(func(path string) error {
+		fileTotal, fileMatched, err := extractAuditFile(path, out, filters, redactor)
+		total += fileTotal
+		matched += fileMatched
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(o.ErrOut, "extracted %d of %d events\n", matched, total)
+	return nil
+}
+
+// walkAuditFiles calls handle for every file of the logs, directories are walked.
+func (o *ExtractOptions) walkAuditFiles(handle func(path string) error) error {
+	for _, filename := range o.filenames {
+		err := filepath.Walk(filename, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			return handle(path)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timeSpan reads the time span of all the logs.
+func (o *ExtractOptions) timeSpan() (util.TimeSpan, error) {
+	span := util.TimeSpan{}
+	err := o.walkAuditFiles(func(path string) error {
+		_, err := audit.ScanFile(path, func(event *auditv1.Event, _, _ []byte) error {
+			span.Include(event.RequestReceivedTimestamp.Time)
+			return nil
+		})
+		return err
+	})
+	return span, err
+}
+
+// extractAuditFile copies the lines of the audit log that pass the filters to out.  The lines are copied as they are,
+// unless a redactor is given.
+func extractAuditFile(path string, out io.Writer, filters audit.Filters, redactor *auditRedactor) (int, int, error) {
+	total, matched := 0, 0
+	_, err := audit.ScanFile(path, func(event *auditv1.Event, line, prefix []byte) error {
+		total++
+		if !filters.Matches(event) {
+			return nil
+		}
+		matched++
+
+		if redactor == nil {
+			_, err := out.Write(append(line, '\n'))
+			return err
+		}
+
+		redacted, err := json.Marshal(redactor.Redact(event))
+		if err != nil {
+			return err
+		}
+		if len(prefix) > 0 {
+			if _, err := fmt.Fprintf(out, "%s ", redactor.hostname(string(prefix))); err != nil {
+				return err
+			}
+		}
+		_, err = out.Write(append(redacted, '\n'))
+		return err
+	})
+	return total, matched, err
+}
@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+)
+
+func PrintFailureClusters(writer io.Writer, numToDisplay int, clusters []*audit.FailureCluster) {
+	total := 0
+	for _, cluster := range clusters {
+		total += cluster.Count
+	}
+	fmt.Fprintf(writer, "%d failed requests in %d clusters\n", total, len(clusters))
+	if len(clusters) > numToDisplay {
+		clusters = clusters[:numToDisplay]
+	}
+
+	for _, cluster := range clusters {
+		reason := cluster.Reason
+		if len(reason) == 0 {
+			reason = "<none>"
+		}
+		fmt.Fprintf(writer, "\n%dx [%d %s] %s\n", cluster.Count, cluster.Code, reason, cluster.Template)
+
+		w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "  between\t%s - %s\n", cluster.First.UTC().Format(time.RFC3339), cluster.Last.UTC().Format(time.RFC3339))
+		fmt.Fprintf(w, "  users\t%s\n", strings.Join(truncateList(cluster.Users.List(), 5), ", "))
+		fmt.Fprintf(w, "  requests\t%s\n", strings.Join(truncateList(cluster.Resources.List(), 5), ", "))
+		examples := []string{}
+		for _, auditID := range cluster.ExampleAuditIDs {
+			examples = append(examples, string(auditID))
+		}
+		fmt.Fprintf(w, "  examples\t%s\n", strings.Join(examples, ", "))
+		w.Flush()
+	}
+}
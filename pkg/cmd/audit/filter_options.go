@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+	"github.com/openshift/cluster-debug-tools/pkg/util"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	utilpointer "k8s.io/utils/pointer"
+)
+
+// AuditFilterOptions holds the flags that select audit events, they are shared by the audit command and the
+// subcommands that work on a subset of the events.
+type AuditFilterOptions struct {
+	verbs             []string
+	resources         []string
+	subresources      []string
+	namespaces        []string
+	names             []string
+	users             []string
+	fieldManagers     []string
+	uids              []string
+	failedOnly        bool
+	httpStatusCodes   []int32
+	stages            []string
+	duration          string
+	podsecurityfilter string
+	kinds             []string
+	dryRun            string
+	patchTypes        []string
+	applyConflicts    bool
+	timeWindow        *util.TimeWindowOptions
+
+	// discovery is a snapshot path or DiscoveryFromCluster, it resolves kinds, short names and categories.
+	discovery   string
+	configFlags *genericclioptions.ConfigFlags
+	resolver    *audit.ResourceResolver
+}
+
+func NewAuditFilterOptions() *AuditFilterOptions {
+	return &AuditFilterOptions{
+		stages: []string{
+			// We are making RequestReceived the default stage,
+			// this will provide a protection against double counting of events.
+			"ResponseComplete",
+		},
+		timeWindow: util.NewTimeWindowOptions(),
+		configFlags: &genericclioptions.ConfigFlags{
+			KubeConfig: utilpointer.String(""),
+			Context:    utilpointer.String(""),
+		},
+	}
+}
+
+func (o *AuditFilterOptions) AddFlags(flags *pflag.FlagSet) {
+	flags.StringSliceVar(&o.uids, "uid", o.uids, "Only match specific UIDs")
+	flags.StringSliceVar(&o.verbs, "verb", o.verbs, "Filter result of search to only contain the specified verb (eg. 'update', 'get', etc..)")
+	flags.StringSliceVar(&o.resources, "resource", o.resources, "Filter result of search to only contain the specified resource.)")
+	flags.StringSliceVar(&o.subresources, "subresource", o.subresources, "Filter result of search to only contain the specified subresources.  \"-*\" means no subresource)")
+	flags.StringSliceVarP(&o.namespaces, "namespace", "n", o.namespaces, "Filter result of search to only contain the specified namespace.")
+	flags.StringSliceVar(&o.names, "name", o.names, "Filter result of search to only contain the specified name.)")
+	flags.StringSliceVar(&o.users, "user", o.users, "Filter result of search to only contain the specified user.)")
+	flags.StringSliceVar(&o.fieldManagers, "field-manager", o.fieldManagers, "Filter result of search to only contain the specified fieldManager.)")
+	flags.BoolVar(&o.failedOnly, "failed-only", false, "Filter result of search to only contain http failures.)")
+	flags.Int32SliceVar(&o.httpStatusCodes, "http-status-code", o.httpStatusCodes, "Filter result of search to only certain http status codes (200,429).")
+	flags.StringVar(&o.dryRun, "dry-run", o.dryRun, "Filter the dry-run requests (dryRun=All), they are admitted but never persisted. Possible values: 'only', 'exclude'.")
+	flags.StringSliceVar(&o.patchTypes, "patch-type", o.patchTypes, "Filter result of search to only contain the patches of the specified type: "+strings.Join(audit.PatchTypes, ", ")+". The type is guessed from the query and the body of the patch, the body is only logged at the Request level and above.")
+	flags.BoolVar(&o.applyConflicts, "apply-conflicts", false, "Filter result of search to only contain the server-side apply patches that failed on field manager conflicts.")
+	o.timeWindow.AddFlags(flags)
+	flags.StringSliceVarP(&o.stages, "stage", "s", o.stages, "Filter result by event stage (eg. 'RequestReceived', 'ResponseComplete'), if omitted all stages will be included)")
+	flags.StringVar(&o.duration, "duration", o.duration, "Filter all requests that didn't take longer than the specified timeout to complete. Keep in mind that requests usually don't take exactly the specified time. Adding a second or two should give you what you want.")
+	flags.StringVar(&o.podsecurityfilter, "podsecurityviolations", "", "Filter pod security admission violations. Possible values: 'pod', 'all'; for either pod violations only, or violations of both pods and pod controllers")
+	flags.StringSliceVar(&o.kinds, "kind", o.kinds, "Filter result of search to only contain the specified kind (eg. 'Deployment', 'Route.route.openshift.io'). Requires --discovery.")
+	flags.StringVar(&o.discovery, "discovery", o.discovery, "Resolve kinds, short names and categories (eg. --resource=all) with a discovery snapshot: a kubectl discovery cache, APIResourceLists or CRDs like in a must-gather. Use 'cluster' to read the discovery of the cluster of --kubeconfig.")
+	o.configFlags.AddFlags(flags)
+}
+
+func (o *AuditFilterOptions) Validate() error {
+	if len(o.duration) > 0 {
+		if _, err := time.ParseDuration(o.duration); err != nil {
+			return fmt.Errorf("incorrect duration specified, err %v", err)
+		}
+	}
+	if err := validatePodSecurityFilter(o.podsecurityfilter); err != nil {
+		return err
+	}
+	if err := o.timeWindow.Validate(); err != nil {
+		return err
+	}
+	switch o.dryRun {
+	case "", audit.DryRunOnly, audit.DryRunExclude:
+	default:
+		return fmt.Errorf("unsupported --dry-run value %q, available values are: [only,exclude]", o.dryRun)
+	}
+	for _, patchType := range o.patchTypes {
+		if value := strings.TrimPrefix(patchType, "-"); !strings.HasSuffix(value, "*") && !sets.NewString(audit.PatchTypes...).Has(value) {
+			return fmt.Errorf("unsupported --patch-type value %q, available values are: [%s]", patchType, strings.Join(audit.PatchTypes, ","))
+		}
+	}
+	if len(o.kinds) > 0 && len(o.discovery) == 0 {
+		return fmt.Errorf("--kind requires --discovery")
+	}
+	return nil
+}
+
+// ToResolver loads the discovery selected by --discovery, it returns nil when no discovery was selected.
+func (o *AuditFilterOptions) ToResolver() (*audit.ResourceResolver, error) {
+	if o.resolver != nil || len(o.discovery) == 0 {
+		return o.resolver, nil
+	}
+
+	var err error
+	if o.discovery == audit.DiscoveryFromCluster {
+		o.resolver, err = audit.DiscoverResources(o.configFlags)
+	} else {
+		o.resolver, err = audit.LoadDiscoverySnapshot(o.discovery)
+	}
+	return o.resolver, err
+}
+
+// NeedsTimeSpan is true when the time window is relative to the events, so ToFilters needs their time span.
+func (o *AuditFilterOptions) NeedsTimeSpan() bool {
+	return o.timeWindow.NeedsTimeSpan()
+}
+
+// ToFilterBuilder describes the query selected by the flags, span is the time span of the events it is applied to.
+func (o *AuditFilterOptions) ToFilterBuilder(span util.TimeSpan) (*audit.FilterBuilder, error) {
+	resolver, err := o.ToResolver()
+	if err != nil {
+		return nil, err
+	}
+	after, before, err := o.timeWindow.ToTimeWindow(span)
+	if err != nil {
+		return nil, err
+	}
+	builder := &audit.FilterBuilder{
+		UIDs:                  o.uids,
+		Verbs:                 o.verbs,
+		Resources:             o.resources,
+		Kinds:                 o.kinds,
+		Subresources:          o.subresources,
+		Namespaces:            o.namespaces,
+		Names:                 o.names,
+		Users:                 o.users,
+		FieldManagers:         o.fieldManagers,
+		Stages:                o.stages,
+		HTTPStatusCodes:       o.httpStatusCodes,
+		FailedOnly:            o.failedOnly,
+		DryRun:                o.dryRun,
+		PatchTypes:            o.patchTypes,
+		ApplyConflictsOnly:    o.applyConflicts,
+		After:                 after,
+		Before:                before,
+		PodSecurityViolations: o.podsecurityfilter,
+		Resolver:              resolver,
+	}
+	if len(o.duration) > 0 {
+		if builder.MaxDuration, err = time.ParseDuration(o.duration); err != nil {
+			return nil, err
+		}
+	}
+	return builder, nil
+}
+
+// ToFilters builds the filters selected by the flags, span is the time span of the events the filters are applied to.
+func (o *AuditFilterOptions) ToFilters(span util.TimeSpan) (audit.Filters, error) {
+	builder, err := o.ToFilterBuilder(span)
+	if err != nil {
+		return nil, err
+	}
+	return builder.Build()
+}
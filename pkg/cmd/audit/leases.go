@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+)
+
+func PrintLeaseTimelines(writer io.Writer, timelines []*audit.LeaseTimeline, gapThreshold time.Duration) {
+	w := tabwriter.NewWriter(writer, 20, 0, 0, ' ', tabwriter.DiscardEmptyColumns)
+	defer w.Flush()
+
+	nodeLeases := []*audit.LeaseTimeline{}
+	for _, timeline := range timelines {
+		if timeline.IsNodeLease() {
+			nodeLeases = append(nodeLeases, timeline)
+			continue
+		}
+
+		fmt.Fprintf(w, "\n%s %s/%s: %d renewals, %d leader transitions, %d releases, %d gaps > %s\n",
+			timeline.Resource, timeline.Namespace, timeline.Name, len(timeline.Renewals), len(timeline.Transitions), len(timeline.Releases), len(timeline.Gaps), gapThreshold)
+		if len(timeline.Renewals) == 0 {
+			continue
+		}
+		if count := timeline.HolderIsUsername(); count > 0 {
+			fmt.Fprintf(w, "  %d renewals were recorded without their request body, their holder is the requesting user and the transitions between replicas sharing a user are not detected\n", count)
+		}
+		if first := timeline.Renewals[0]; !first.Released {
+			fmt.Fprintf(w, "%s\t holder %s\t [%s]\n", first.Timestamp.UTC().Format("15:04:05"), first.Holder, first.Username)
+		}
+		transitions := sets.NewInt(timeline.Transitions...)
+		for _, i := range mergeIndexes(timeline.Transitions, timeline.Gaps, timeline.Releases) {
+			renewal := timeline.Renewals[i]
+			if i > 0 {
+				previous := timeline.Renewals[i-1]
+				if gap := renewal.Timestamp.Sub(previous.Timestamp); gap > gapThreshold {
+					fmt.Fprintf(w, "%s\t gap of %s since %s\n", renewal.Timestamp.UTC().Format("15:04:05"), gap, previous.Timestamp.UTC().Format("15:04:05"))
+				}
+			}
+			switch {
+			case renewal.Released:
+				fmt.Fprintf(w, "%s\t released\t [%s]\n", renewal.Timestamp.UTC().Format("15:04:05"), renewal.Username)
+			case transitions.Has(i):
+				fmt.Fprintf(w, "%s\t holder %s -> %s\t [%s]\n", renewal.Timestamp.UTC().Format("15:04:05"), timeline.PreviousHolder(i), renewal.Holder, renewal.Username)
+			}
+		}
+	}
+
+	if len(nodeLeases) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nNode heartbeats (%d nodes):\n", len(nodeLeases))
+	for _, timeline := range nodeLeases {
+		fmt.Fprintf(w, "%s\t %d renewals, max gap %s\n", timeline.Name, len(timeline.Renewals), timeline.MaxGap())
+		for _, i := range timeline.Gaps {
+			renewal := timeline.Renewals[i]
+			previous := timeline.Renewals[i-1]
+			fmt.Fprintf(w, "\t gap of %s from %s to %s\n", renewal.Timestamp.Sub(previous.Timestamp),
+				previous.Timestamp.UTC().Format("15:04:05"), renewal.Timestamp.UTC().Format("15:04:05"))
+		}
+	}
+}
+
+// mergeIndexes returns the sorted union of the index lists.
+func mergeIndexes(lists ...[]int) []int {
+	seen := map[int]bool{}
+	ret := []int{}
+	for _, list := range lists {
+		for _, i := range list {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			ret = append(ret, i)
+		}
+	}
+	sort.Ints(ret)
+	return ret
+}
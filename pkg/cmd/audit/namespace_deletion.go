@@ -0,0 +1,196 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+)
+
+var (
+	namespaceDeletionExample = `
+	# explain why the deletion of the e2e-test-foo namespace got stuck
+	%[1]s audit namespace-deletion e2e-test-foo -f audit.log
+
+	# the same for all audit logs in a must-gather
+	%[1]s audit namespace-deletion e2e-test-foo -f must-gather/audit_logs/kube-apiserver
+`
+)
+
+type NamespaceDeletionOptions struct {
+	namespace string
+	filenames []string
+
+	genericclioptions.IOStreams
+}
+
+func NewNamespaceDeletionOptions(streams genericclioptions.IOStreams) *NamespaceDeletionOptions {
+	return &NamespaceDeletionOptions{
+		IOStreams: streams,
+	}
+}
+
+func NewCmdNamespaceDeletion(parentName string, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewNamespaceDeletionOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:          "namespace-deletion NAMESPACE -f=audit.file [flags]",
+		Short:        "Reconstructs the deletion of a namespace from the audit logs.",
+		Example:      fmt.Sprintf(namespaceDeletionExample, parentName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.Complete(c, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			if err := o.Run(); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&o.filenames, "filename", "f", o.filenames, "Search for audit logs that contains specified URI")
+
+	return cmd
+}
+
+func (o *NamespaceDeletionOptions) Complete(command *cobra.Command, args []string) error {
+	if len(args) >= 1 {
+		o.namespace = args[0]
+	}
+	return nil
+}
+
+func (o *NamespaceDeletionOptions) Validate() error {
+	if len(o.namespace) == 0 {
+		return fmt.Errorf("the namespace to inspect must be specified")
+	}
+	if len(o.filenames) == 0 {
+		return fmt.Errorf("at least one audit log must be specified with -f")
+	}
+	return nil
+}
+
+func (o *NamespaceDeletionOptions) Run() error {
+	events, err := GetEvents(o.ErrOut, o.filenames...)
+	if err != nil {
+		return err
+	}
+	events = audit.Filters{&audit.FilterByStage{Stages: sets.NewString(string(auditv1.StageResponseComplete))}}.FilterEvents(events...)
+
+	deletion, err := audit.AnalyzeNamespaceDeletion(o.namespace, events)
+	if err != nil {
+		return err
+	}
+	PrintNamespaceDeletion(o.Out, deletion)
+	return nil
+}
+
+func PrintNamespaceDeletion(writer io.Writer, deletion *audit.NamespaceDeletion) {
+	w := tabwriter.NewWriter(writer, 20, 0, 0, ' ', tabwriter.DiscardEmptyColumns)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "Namespace %q deleted at %s by %s [%d]\n",
+		deletion.Namespace,
+		deletion.Delete.RequestReceivedTimestamp.UTC().Format(time.RFC3339),
+		deletion.Delete.User.Username,
+		audit.ResponseCode(deletion.Delete))
+
+	fmt.Fprintf(w, "\nFinalize and status updates (%d):\n", len(deletion.NamespaceUpdates))
+	for _, event := range deletion.NamespaceUpdates {
+		fmt.Fprintf(w, "%s [%6s][%3d]\t %s\t [%s]\n",
+			event.RequestReceivedTimestamp.UTC().Format("15:04:05"),
+			strings.ToUpper(event.Verb),
+			audit.ResponseCode(event),
+			event.RequestURI,
+			event.User.Username)
+		for _, line := range describeNamespaceObject(event) {
+			fmt.Fprintf(w, "\t %s\n", line)
+		}
+	}
+
+	fmt.Fprintf(w, "\nCollection deletes issued by the namespace controller (%d resources):\n", len(deletion.CollectionDeletes))
+	for _, activity := range deletion.CollectionDeletes {
+		fmt.Fprintf(w, "%8s [%s]\t %s\t last: %s\n",
+			fmt.Sprintf("%dx", activity.Count),
+			statusCodesString(activity.StatusCodeToCount),
+			activity.Resource,
+			activity.Last.UTC().Format("15:04:05"))
+	}
+
+	fmt.Fprintf(w, "\nObject deletes issued by the namespace controller (%d resources):\n", len(deletion.ObjectDeletes))
+	for _, activity := range deletion.ObjectDeletes {
+		fmt.Fprintf(w, "%8s [%s]\t %s\t last: %s\n",
+			fmt.Sprintf("%dx", activity.Count),
+			statusCodesString(activity.StatusCodeToCount),
+			activity.Resource,
+			activity.Last.UTC().Format("15:04:05"))
+	}
+
+	fmt.Fprintf(w, "\nWrites by other users after the deletion (%d):\n", len(deletion.RemainingActivity))
+	for _, activity := range deletion.RemainingActivity {
+		fmt.Fprintf(w, "%8s [%s] [%s]\t %s\t [%s] last: %s\n",
+			fmt.Sprintf("%dx", activity.Count),
+			strings.Join(activity.Verbs.List(), ","),
+			statusCodesString(activity.StatusCodeToCount),
+			activity.Resource,
+			activity.User,
+			activity.Last.UTC().Format("15:04:05"))
+	}
+}
+
+// describeNamespaceObject lists the finalizers and the true conditions of the namespace from the request or response
+// body, those are only present at Request or RequestResponse audit level.
+func describeNamespaceObject(event *auditv1.Event) []string {
+	body := event.RequestObject
+	if event.ResponseObject != nil {
+		body = event.ResponseObject
+	}
+	if body == nil || len(body.Raw) == 0 {
+		return nil
+	}
+	namespace := &corev1.Namespace{}
+	if err := json.Unmarshal(body.Raw, namespace); err != nil || namespace.Kind != "Namespace" {
+		return nil
+	}
+
+	ret := []string{}
+	finalizers := []string{}
+	for _, finalizer := range namespace.Spec.Finalizers {
+		finalizers = append(finalizers, string(finalizer))
+	}
+	finalizers = append(finalizers, namespace.Finalizers...)
+	ret = append(ret, fmt.Sprintf("finalizers: [%s]", strings.Join(finalizers, ",")))
+	for _, condition := range namespace.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		ret = append(ret, fmt.Sprintf("%s: %s", condition.Type, condition.Message))
+	}
+	return ret
+}
+
+func statusCodesString(statusCodeToCount map[int32]int) string {
+	codeStrings := []string{}
+	for code, count := range statusCodeToCount {
+		codeStrings = append(codeStrings, fmt.Sprintf("%v-%v", code, count))
+	}
+	sort.Strings(codeStrings)
+	return strings.Join(codeStrings, ",")
+}
@@ -0,0 +1,205 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/audit/policy"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+)
+
+var (
+	policySimulateExample = `
+	# estimate what the candidate policy would log compared to the recorded audit logs
+	%[1]s audit policy-simulate --policy=policy.yaml -f must-gather/audit_logs/kube-apiserver
+
+	# show the 25 biggest groups of requests the policy would drop
+	%[1]s audit policy-simulate --policy=policy.yaml -f audit.log --top=25
+`
+)
+
+type PolicySimulateOptions struct {
+	filterOptions *AuditFilterOptions
+	filenames     []string
+	policyFile    string
+	top           int
+
+	genericclioptions.IOStreams
+}
+
+func NewPolicySimulateOptions(streams genericclioptions.IOStreams) *PolicySimulateOptions {
+	return &PolicySimulateOptions{
+		filterOptions: NewAuditFilterOptions(),
+		top:           10,
+		IOStreams:     streams,
+	}
+}
+
+func NewCmdPolicySimulate(parentName string, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewPolicySimulateOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:          "policy-simulate --policy=policy.yaml -f=audit.file [flags]",
+		Short:        "Evaluates an audit policy against recorded audit logs.",
+		Example:      fmt.Sprintf(policySimulateExample, parentName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.Complete(c, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			if err := o.Run(); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&o.filenames, "filename", "f", o.filenames, "Search for audit logs that contains specified URI")
+	cmd.Flags().StringVar(&o.policyFile, "policy", o.policyFile, "The audit.k8s.io/v1 Policy to evaluate.")
+	cmd.Flags().IntVar(&o.top, "top", o.top, "The number of groups of dropped requests to display.")
+	o.filterOptions.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *PolicySimulateOptions) Complete(command *cobra.Command, args []string) error {
+	return nil
+}
+
+func (o *PolicySimulateOptions) Validate() error {
+	if len(o.policyFile) == 0 {
+		return fmt.Errorf("the policy to evaluate must be specified with --policy")
+	}
+	if len(o.filenames) == 0 {
+		return fmt.Errorf("at least one audit log must be specified with -f")
+	}
+	return o.filterOptions.Validate()
+}
+
+func (o *PolicySimulateOptions) Run() error {
+	auditPolicy, err := policy.LoadPolicyFromFile(o.policyFile)
+	if err != nil {
+		return err
+	}
+	events, err := GetEvents(o.ErrOut, o.filenames...)
+	if err != nil {
+		return err
+	}
+	filters, err := o.filterOptions.ToFilters(audit.EventsTimeSpan(events))
+	if err != nil {
+		return err
+	}
+	events = filters.FilterEvents(events...)
+
+	PrintPolicySimulation(o.Out, audit.SimulatePolicy(auditPolicy, events), o.top)
+	return nil
+}
+
+func PrintPolicySimulation(writer io.Writer, simulation *audit.PolicySimulation, numToDisplay int) {
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "RULE\tLEVEL\tREQUESTS\tEVENTS\tBYTES\tMISSING BODIES\tMATCHES\n")
+	for _, rule := range simulation.Rules {
+		index, level, description := "-", string(policy.DefaultAuditLevel), "(no rule matched)"
+		if rule.Rule != nil {
+			index = fmt.Sprintf("%d", rule.Index)
+			level = string(rule.Rule.Level)
+			description = describePolicyRule(rule.Rule)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%d\t%s\n", index, level, rule.Requests, rule.Events, formatBytes(rule.Bytes), rule.MissingBodies, description)
+	}
+
+	fmt.Fprintf(w, "\nLEVEL\tREQUESTS\tEVENTS\tBYTES\tMISSING BODIES\n")
+	for _, level := range []auditinternal.Level{auditinternal.LevelNone, auditinternal.LevelMetadata, auditinternal.LevelRequest, auditinternal.LevelRequestResponse} {
+		volume, ok := simulation.Levels[level]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%d\n", level, volume.Requests, volume.Events, formatBytes(volume.Bytes), volume.MissingBodies)
+	}
+	w.Flush()
+
+	type droppedWithCount struct {
+		name  string
+		count int
+	}
+	dropped := []droppedWithCount{}
+	for name, count := range simulation.Dropped {
+		dropped = append(dropped, droppedWithCount{name: name, count: count})
+	}
+	sort.Slice(dropped, func(i, j int) bool {
+		if dropped[i].count != dropped[j].count {
+			return dropped[i].count > dropped[j].count
+		}
+		return dropped[i].name < dropped[j].name
+	})
+	if len(dropped) > numToDisplay {
+		dropped = dropped[:numToDisplay]
+	}
+	fmt.Fprintf(writer, "\nTop %d dropped requests:\n", numToDisplay)
+	for _, d := range dropped {
+		fmt.Fprintf(writer, "%8s %s\n", fmt.Sprintf("%dx", d.count), d.name)
+	}
+}
+
+// describePolicyRule summarizes the matching criteria of a rule on a single line.
+func describePolicyRule(rule *auditinternal.PolicyRule) string {
+	parts := []string{}
+	if len(rule.Users) > 0 {
+		parts = append(parts, fmt.Sprintf("users=%s", strings.Join(rule.Users, ",")))
+	}
+	if len(rule.UserGroups) > 0 {
+		parts = append(parts, fmt.Sprintf("groups=%s", strings.Join(rule.UserGroups, ",")))
+	}
+	if len(rule.Verbs) > 0 {
+		parts = append(parts, fmt.Sprintf("verbs=%s", strings.Join(rule.Verbs, ",")))
+	}
+	if len(rule.Resources) > 0 {
+		resources := sets.NewString()
+		for _, groupResources := range rule.Resources {
+			for _, resource := range groupResources.Resources {
+				resources.Insert(strings.TrimSuffix(resource+"."+groupResources.Group, "."))
+			}
+			if len(groupResources.Resources) == 0 {
+				resources.Insert("*." + groupResources.Group)
+			}
+		}
+		parts = append(parts, fmt.Sprintf("resources=%s", strings.Join(resources.List(), ",")))
+	}
+	if len(rule.Namespaces) > 0 {
+		parts = append(parts, fmt.Sprintf("namespaces=%s", strings.Join(rule.Namespaces, ",")))
+	}
+	if len(rule.NonResourceURLs) > 0 {
+		parts = append(parts, fmt.Sprintf("nonResourceURLs=%s", strings.Join(rule.NonResourceURLs, ",")))
+	}
+	if len(parts) == 0 {
+		return "(everything)"
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatBytes(bytes int64) string {
+	switch {
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%.1fGiB", float64(bytes)/(1<<30))
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1fMiB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1fKiB", float64(bytes)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", bytes)
+}
@@ -0,0 +1,191 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+	"github.com/openshift/cluster-debug-tools/pkg/cmd/psa"
+)
+
+const podSecurityAuditViolationsAnnotation = "pod-security.kubernetes.io/audit-violations"
+
+// podControllerResources are the workload resources whose pod templates are checked by the pod security admission.
+var podControllerResources = map[schema.GroupResource]schema.GroupVersionKind{
+	{Group: "apps", Resource: "deployments"}:                    {Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Group: "apps", Resource: "daemonsets"}:                     {Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	{Group: "apps", Resource: "statefulsets"}:                   {Group: "apps", Version: "v1", Kind: "StatefulSet"},
+	{Group: "apps", Resource: "replicasets"}:                    {Group: "apps", Version: "v1", Kind: "ReplicaSet"},
+	{Group: "batch", Resource: "jobs"}:                          {Group: "batch", Version: "v1", Kind: "Job"},
+	{Group: "batch", Resource: "cronjobs"}:                      {Group: "batch", Version: "v1", Kind: "CronJob"},
+	{Group: "", Resource: "replicationcontrollers"}:             {Version: "v1", Kind: "ReplicationController"},
+	{Group: "apps.openshift.io", Resource: "deploymentconfigs"}: {Group: "apps.openshift.io", Version: "v1", Kind: "DeploymentConfig"},
+}
+
+// podCreators maps the controllers that create pods to the workload kind and the number of generated name segments
+// they append, eg. the replicaset controller creates <deployment>-<pod-template-hash>-<random>.
+var podCreators = map[string]struct {
+	gvk               schema.GroupVersionKind
+	generatedSuffixes int
+}{
+	"system:serviceaccount:kube-system:replicaset-controller":  {gvk: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, generatedSuffixes: 2},
+	"system:serviceaccount:kube-system:daemon-set-controller":  {gvk: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "DaemonSet"}, generatedSuffixes: 1},
+	"system:serviceaccount:kube-system:statefulset-controller": {gvk: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"}, generatedSuffixes: 1},
+	"system:serviceaccount:kube-system:job-controller":         {gvk: schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, generatedSuffixes: 1},
+}
+
+// BuildPodSecurityViolations aggregates the pod security violations recorded in the audit annotations per namespace
+// and workload.  The result has the same shape as the psa-check output, without the live pod and controller objects.
+func BuildPodSecurityViolations(events []*auditv1.Event) *psa.PodSecurityViolationList {
+	violations := map[string]*psa.PodSecurityViolation{}
+	keys := []string{}
+	for _, event := range events {
+		annotation, ok := event.Annotations[podSecurityAuditViolationsAnnotation]
+		if !ok {
+			continue
+		}
+		level, eventViolations := psa.ParseAuditViolations(annotation)
+		if len(level) == 0 {
+			continue
+		}
+
+		ns, gvr, name, _ := audit.URIToParts(event.RequestURI)
+		if event.ObjectRef != nil {
+			ns = event.ObjectRef.Namespace
+			if len(event.ObjectRef.Name) > 0 {
+				name = event.ObjectRef.Name
+			}
+		}
+		if len(name) == 0 {
+			name = nameFromBody(event)
+		}
+		owner := workloadOwner(event, gvr.GroupResource(), ns, name)
+
+		key := strings.Join([]string{ns, owner.Kind, owner.Name}, "/")
+		if len(owner.Name) == 0 {
+			// pods logged without their name or body, keep the pods of different creators apart
+			key = key + "/" + event.User.Username
+		}
+		violation, ok := violations[key]
+		if !ok {
+			violation = &psa.PodSecurityViolation{
+				Namespace:      ns,
+				Level:          level,
+				PodControllers: []any{owner},
+			}
+			violations[key] = violation
+			keys = append(keys, key)
+		}
+		if gvr.Group == "" && gvr.Resource == "pods" && len(name) > 0 && (len(violation.PodName) == 0 || len(name) < len(violation.PodName)) {
+			violation.PodName = name
+		}
+		for _, eventViolation := range eventViolations {
+			if !containsString(violation.Violations, eventViolation) {
+				violation.Violations = append(violation.Violations, eventViolation)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	ret := &psa.PodSecurityViolationList{}
+	for _, key := range keys {
+		sort.Strings(violations[key].Violations)
+		ret.Items = append(ret.Items, *violations[key])
+	}
+	return ret
+}
+
+// workloadOwner finds the workload the request is about, without a live cluster it has to be guessed for pods that
+// were logged without their body.
+func workloadOwner(event *auditv1.Event, gr schema.GroupResource, namespace, name string) *metav1.PartialObjectMetadata {
+	newOwner := func(gvk schema.GroupVersionKind, name string) *metav1.PartialObjectMetadata {
+		owner := &metav1.PartialObjectMetadata{}
+		owner.APIVersion, owner.Kind = gvk.ToAPIVersionAndKind()
+		owner.Namespace = namespace
+		owner.Name = name
+		return owner
+	}
+
+	if gvk, ok := podControllerResources[gr]; ok {
+		return newOwner(gvk, name)
+	}
+
+	pod := &corev1.Pod{}
+	if event.RequestObject != nil && json.Unmarshal(event.RequestObject.Raw, pod) == nil {
+		for _, ownerRef := range pod.OwnerReferences {
+			if ownerRef.Controller == nil || !*ownerRef.Controller {
+				continue
+			}
+			gvk := schema.FromAPIVersionAndKind(ownerRef.APIVersion, ownerRef.Kind)
+			if gvk.Kind == "ReplicaSet" {
+				// <deployment>-<pod-template-hash>
+				return newOwner(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, trimNameSegments(ownerRef.Name, 1))
+			}
+			return newOwner(gvk, ownerRef.Name)
+		}
+	}
+
+	if creator, ok := podCreators[event.User.Username]; ok && len(name) > 0 {
+		return newOwner(creator.gvk, trimNameSegments(name, creator.generatedSuffixes))
+	}
+	// a bare pod, or a pod of an unknown controller named after its generateName prefix
+	return newOwner(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, strings.TrimSuffix(name, "-"))
+}
+
+// nameFromBody reads the name of created objects, those are not part of the request URI.  The generateName is
+// returned when the request has no name and the response was not recorded.
+func nameFromBody(event *auditv1.Event) string {
+	generateName := ""
+	for _, unknown := range []*runtime.Unknown{event.ResponseObject, event.RequestObject} {
+		if unknown == nil {
+			continue
+		}
+		object := &metav1.PartialObjectMetadata{}
+		if err := json.Unmarshal(unknown.Raw, object); err != nil {
+			continue
+		}
+		if len(object.Name) > 0 {
+			return object.Name
+		}
+		if len(generateName) == 0 {
+			generateName = object.GenerateName
+		}
+	}
+	return generateName
+}
+
+// trimNameSegments removes the last n dash separated segments of a generated name.
+func trimNameSegments(name string, n int) string {
+	segments := strings.Split(name, "-")
+	if len(segments) <= n {
+		return name
+	}
+	return strings.Join(segments[:len(segments)-n], "-")
+}
+
+func containsString(items []string, item string) bool {
+	for _, current := range items {
+		if current == item {
+			return true
+		}
+	}
+	return false
+}
+
+func PrintPodSecurityViolations(writer io.Writer, violations *psa.PodSecurityViolationList) error {
+	printer, err := printers.NewTypeSetter(scheme.Scheme).WrapToPrinter(&printers.JSONPrinter{}, nil)
+	if err != nil {
+		return err
+	}
+	return printer.PrintObj(violations, writer)
+}
@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"testing"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestBuildPodSecurityViolations(t *testing.T) {
+	newPodCreate := func(user, body string) *auditv1.Event {
+		event := &auditv1.Event{
+			Level:      auditv1.LevelMetadata,
+			Verb:       "create",
+			RequestURI: "/api/v1/namespaces/foo/pods",
+			User:       authnv1.UserInfo{Username: user},
+			ObjectRef:  &auditv1.ObjectReference{Namespace: "foo", Resource: "pods"},
+			Annotations: map[string]string{
+				podSecurityAuditViolationsAnnotation: `would violate PodSecurity "restricted:latest": runAsNonRoot != true (pod or container "foo" must set securityContext.runAsNonRoot=true)`,
+			},
+		}
+		if len(body) > 0 {
+			event.Level = auditv1.LevelRequest
+			event.RequestObject = &runtime.Unknown{Raw: []byte(body)}
+		}
+		return event
+	}
+
+	events := []*auditv1.Event{
+		newPodCreate("system:serviceaccount:kube-system:replicaset-controller", `{"kind":"Pod","apiVersion":"v1","metadata":{"generateName":"web-7d4b9c8f5-"}}`),
+		newPodCreate("system:serviceaccount:kube-system:job-controller", `{"kind":"Pod","apiVersion":"v1","metadata":{"generateName":"backup-28123456-"}}`),
+		newPodCreate("alice", `{"kind":"Pod","apiVersion":"v1","metadata":{"generateName":"debug-"}}`),
+		// without the body only the creator is known
+		newPodCreate("alice", ""),
+		newPodCreate("bob", ""),
+	}
+
+	violations := BuildPodSecurityViolations(events)
+	owners := map[string]bool{}
+	for _, violation := range violations.Items {
+		if len(violation.PodControllers) != 1 {
+			t.Fatalf("expected one owner, got %v", violation.PodControllers)
+		}
+		owner := violation.PodControllers[0].(*metav1.PartialObjectMetadata)
+		owners[owner.Kind+"/"+owner.Name] = true
+	}
+	for _, expected := range []string{"Deployment/web", "Job/backup-28123456", "Pod/debug"} {
+		if !owners[expected] {
+			t.Errorf("expected an owner %s, got %v", expected, owners)
+		}
+	}
+	if len(violations.Items) != 5 {
+		t.Errorf("expected the pods without a body to be kept apart by creator, got %d items", len(violations.Items))
+	}
+}
@@ -0,0 +1,31 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+)
+
+func PrintRateAnomalies(writer io.Writer, numToDisplay int, anomalies []*audit.RateAnomaly) {
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	if len(anomalies) > numToDisplay {
+		anomalies = anomalies[:numToDisplay]
+	}
+
+	fmt.Fprintf(w, "KIND\tREQUESTS\tPEAK QPS\tBASELINE QPS\tCLIENT\tWINDOW\n")
+	for _, anomaly := range anomalies {
+		fmt.Fprintf(w, "%s\t%d\t%.1f\t%.2f\t%s\t--after=%s --before=%s\n",
+			anomaly.Kind,
+			anomaly.Requests,
+			anomaly.PeakQPS,
+			anomaly.BaselineQPS,
+			anomaly.Client,
+			anomaly.Start.UTC().Format(time.RFC3339),
+			anomaly.End.UTC().Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,249 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+// redactedQueryParams may contain object names or labels.
+var redactedQueryParams = []string{"labelSelector", "fieldSelector"}
+
+// keptAnnotationPrefixes are the annotations kept by the redaction, the latencies and the decisions carry no
+// identifying value.  Every other annotation is dropped, eg. the authorization reason names the role bindings of the
+// user, the webhook patches carry object content and the pod security violations name containers.
+var keptAnnotationPrefixes = []string{
+	"apiserver.latency.k8s.io/",
+	"authorization.k8s.io/decision",
+}
+
+var (
+	// quotedRegex finds the quoted values of response status messages with the word before them, which tells what they
+	// are, eg. `pods "foo" not found` or `User "alice" cannot list resource "pods" in the namespace "foo"`.
+	quotedRegex = regexp.MustCompile(`(?:([^\s"]+) )?"([^"]*)"`)
+	// the candidates are checked with net.ParseIP, IPv6 first so that the ports of IPv4 addresses are not mistaken for
+	// IPv6 groups
+	ipv6Regex = regexp.MustCompile(`[0-9a-fA-F]*:[0-9a-fA-F]*:[0-9a-fA-F:.]*`)
+	ipv4Regex = regexp.MustCompile(`\b[0-9]{1,3}(\.[0-9]{1,3}){3}\b`)
+)
+
+// keptQuotedValues are the words of status messages that are followed by non-identifying values, the resources, API
+// groups and pod security levels.
+var keptQuotedValues = map[string]bool{
+	"resource":    true,
+	"subresource": true,
+	"group":       true,
+	"PodSecurity": true,
+}
+
+// auditRedactor replaces the identifying values of audit events with salted hashes.  The same value always maps to
+// the same hash for a given salt, so the relations between events are kept.  Platform namespaces and system users
+// other than service accounts and nodes are kept as they are, they are the same on every cluster.
+type auditRedactor struct {
+	salt string
+}
+
+func newAuditRedactor(salt string) *auditRedactor {
+	return &auditRedactor{salt: salt}
+}
+
+func (r *auditRedactor) hash(kind, value string) string {
+	if len(value) == 0 {
+		return value
+	}
+	sum := sha256.Sum256([]byte(r.salt + "/" + kind + "/" + value))
+	return kind + "-" + hex.EncodeToString(sum[:])[:10]
+}
+
+func isPlatformNamespace(namespace string) bool {
+	return namespace == "default" || namespace == "openshift" ||
+		strings.HasPrefix(namespace, "openshift-") || strings.HasPrefix(namespace, "kube-")
+}
+
+func (r *auditRedactor) namespace(namespace string) string {
+	if isPlatformNamespace(namespace) {
+		return namespace
+	}
+	return r.hash("ns", namespace)
+}
+
+func (r *auditRedactor) name(name string) string {
+	return r.hash("name", name)
+}
+
+func (r *auditRedactor) username(username string) string {
+	switch {
+	case strings.HasPrefix(username, "system:serviceaccount:"):
+		parts := strings.SplitN(strings.TrimPrefix(username, "system:serviceaccount:"), ":", 2)
+		if len(parts) != 2 || isPlatformNamespace(parts[0]) {
+			return username
+		}
+		return "system:serviceaccount:" + r.namespace(parts[0]) + ":" + r.name(parts[1])
+	case strings.HasPrefix(username, "system:node:"):
+		// node names usually contain the host IP or the cloud instance
+		return "system:node:" + r.name(strings.TrimPrefix(username, "system:node:"))
+	case strings.HasPrefix(username, "system:"):
+		return username
+	}
+	return r.hash("user", username)
+}
+
+func (r *auditRedactor) group(group string) string {
+	if strings.HasPrefix(group, "system:") {
+		return group
+	}
+	return r.hash("group", group)
+}
+
+func (r *auditRedactor) hostname(hostname string) string {
+	return r.name(hostname)
+}
+
+// requestURI rewrites the namespace and the object name in the path and the selectors in the query.
+func (r *auditRedactor) requestURI(uri string) string {
+	pathAndQuery := strings.SplitN(uri, "?", 2)
+	parts := strings.Split(pathAndQuery[0], "/")
+
+	// parts[0] is empty because of the leading slash, /api/v1/<resource> or /apis/<group>/<version>/<resource>
+	base := -1
+	switch {
+	case len(parts) >= 4 && parts[1] == "api":
+		base = 3
+	case len(parts) >= 5 && parts[1] == "apis":
+		base = 4
+	}
+	if base > 0 {
+		resourceIdx := base
+		if parts[base] == "namespaces" && len(parts) > base+1 {
+			parts[base+1] = r.namespace(parts[base+1])
+			// /namespaces/<name>, /namespaces/<name>/finalize and /namespaces/<name>/status are the namespace itself
+			if len(parts) > base+2 && parts[base+2] != "finalize" && parts[base+2] != "status" {
+				resourceIdx = base + 2
+			}
+		}
+		if resourceIdx != base && len(parts) > resourceIdx+1 {
+			parts[resourceIdx+1] = r.name(parts[resourceIdx+1])
+		}
+		if resourceIdx == base && parts[base] != "namespaces" && len(parts) > base+1 {
+			parts[base+1] = r.name(parts[base+1])
+		}
+	}
+
+	ret := strings.Join(parts, "/")
+	if len(pathAndQuery) < 2 {
+		return ret
+	}
+	values, err := url.ParseQuery(pathAndQuery[1])
+	if err != nil {
+		// we cannot tell what is in there, drop it
+		return ret
+	}
+	for _, param := range redactedQueryParams {
+		if value := values.Get(param); len(value) > 0 {
+			values.Set(param, r.hash("selector", value))
+		}
+	}
+	return ret + "?" + values.Encode()
+}
+
+// Redact returns a copy of the event with the users, user agents, source IPs, namespaces, names and UIDs hashed, the
+// IPs and the identifying quoted values of the response status message hashed, and the request and response bodies, the response status details and
+// the annotations other than the latencies and the decisions stripped.
+func (r *auditRedactor) Redact(event *auditv1.Event) *auditv1.Event {
+	ret := event.DeepCopy()
+
+	ret.RequestURI = r.requestURI(ret.RequestURI)
+	r.redactUser(&ret.User.Username, ret.User.Groups)
+	ret.User.UID = r.hash("uid", ret.User.UID)
+	ret.User.Extra = nil
+	if ret.ImpersonatedUser != nil {
+		r.redactUser(&ret.ImpersonatedUser.Username, ret.ImpersonatedUser.Groups)
+		ret.ImpersonatedUser.UID = r.hash("uid", ret.ImpersonatedUser.UID)
+		ret.ImpersonatedUser.Extra = nil
+	}
+	ret.UserAgent = r.hash("useragent", ret.UserAgent)
+	for i := range ret.SourceIPs {
+		ret.SourceIPs[i] = r.hash("ip", ret.SourceIPs[i])
+	}
+	if ret.ObjectRef != nil {
+		if ret.ObjectRef.Resource == "namespaces" && len(ret.ObjectRef.APIGroup) == 0 {
+			ret.ObjectRef.Name = r.namespace(ret.ObjectRef.Name)
+		} else {
+			ret.ObjectRef.Name = r.name(ret.ObjectRef.Name)
+		}
+		ret.ObjectRef.Namespace = r.namespace(ret.ObjectRef.Namespace)
+		ret.ObjectRef.UID = types.UID(r.hash("uid", string(ret.ObjectRef.UID)))
+	}
+	if ret.ResponseStatus != nil {
+		// the details name the object and the causes repeat the field values
+		ret.ResponseStatus.Details = nil
+		ret.ResponseStatus.Message = r.statusMessage(ret.ResponseStatus.Message)
+	}
+	for key := range ret.Annotations {
+		if !isKeptAnnotation(key) {
+			delete(ret.Annotations, key)
+		}
+	}
+
+	ret.RequestObject = nil
+	ret.ResponseObject = nil
+	if ret.Level == auditv1.LevelRequest || ret.Level == auditv1.LevelRequestResponse {
+		ret.Level = auditv1.LevelMetadata
+	}
+
+	return ret
+}
+
+// statusMessage hashes the IPs and the quoted values of a status message like the same values are hashed in the rest of
+// the event, the namespaces as namespaces, the users as users and the object names as names.
+func (r *auditRedactor) statusMessage(message string) string {
+	for _, regex := range []*regexp.Regexp{ipv6Regex, ipv4Regex} {
+		message = regex.ReplaceAllStringFunc(message, func(candidate string) string {
+			if net.ParseIP(candidate) == nil {
+				return candidate
+			}
+			return r.hash("ip", candidate)
+		})
+	}
+
+	return quotedRegex.ReplaceAllStringFunc(message, func(match string) string {
+		submatches := quotedRegex.FindStringSubmatch(match)
+		word, value := submatches[1], submatches[2]
+		switch {
+		case keptQuotedValues[word]:
+		case word == "namespace" || word == "namespaces":
+			value = r.namespace(value)
+		case word == "User" || word == "user":
+			value = r.username(value)
+		default:
+			// <resource> "<name>", or anything else that may identify the cluster
+			value = r.name(value)
+		}
+		if len(word) == 0 {
+			return `"` + value + `"`
+		}
+		return word + ` "` + value + `"`
+	})
+}
+
+func (r *auditRedactor) redactUser(username *string, groups []string) {
+	*username = r.username(*username)
+	for i := range groups {
+		groups[i] = r.group(groups[i])
+	}
+}
+
+func isKeptAnnotation(key string) bool {
+	for _, prefix := range keptAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
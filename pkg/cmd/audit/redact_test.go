@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *auditv1.Event
+		// secrets must not appear anywhere in the redacted event
+		secrets []string
+		// annotations must be kept as they are
+		annotations []string
+	}{
+		{
+			name: "user, user agent and source IPs",
+			event: &auditv1.Event{
+				User:      authnv1.UserInfo{Username: "alice@example.com", UID: "alice-uid", Groups: []string{"acme-admins"}, Extra: map[string]authnv1.ExtraValue{"scopes": {"alice-scope"}}},
+				UserAgent: "acme-operator/v1.2.3 (linux/amd64) build-host-42",
+				SourceIPs: []string{"203.0.113.7"},
+			},
+			secrets: []string{"alice", "acme", "build-host-42", "203.0.113.7"},
+		},
+		{
+			name: "object reference and request URI",
+			event: &auditv1.Event{
+				RequestURI: "/api/v1/namespaces/customer-ns/secrets/db-password?labelSelector=team%3Dpayments",
+				ObjectRef:  &auditv1.ObjectReference{Resource: "secrets", Namespace: "customer-ns", Name: "db-password", UID: "object-uid"},
+			},
+			secrets: []string{"customer-ns", "db-password", "payments", "object-uid"},
+		},
+		{
+			name: "response status details and message",
+			event: &auditv1.Event{
+				ResponseStatus: &metav1.Status{
+					Message: `secrets "db-password" is forbidden`,
+					Details: &metav1.StatusDetails{Name: "db-password", UID: "secret-uid", Causes: []metav1.StatusCause{{Message: "value customer-value is invalid", Field: "data.customer-field"}}},
+				},
+			},
+			secrets: []string{"db-password", "secret-uid", "customer-value", "customer-field"},
+		},
+		{
+			name: "annotations",
+			event: &auditv1.Event{
+				Annotations: map[string]string{
+					"authorization.k8s.io/decision":                     "allow",
+					"authorization.k8s.io/reason":                       `RBAC: allowed by RoleBinding "alice-binding"`,
+					"apiserver.latency.k8s.io/etcd":                     "1.2ms",
+					"mutation.webhook.admission.k8s.io/round_0_index_0": `{"configuration":"acme-webhook","mutated":true}`,
+					"patch.webhook.admission.k8s.io/round_0_index_0":    `{"patch":[{"op":"add","path":"/metadata/labels/customer-label"}]}`,
+					"pod-security.kubernetes.io/audit-violations":       `would violate PodSecurity "restricted:latest": container "customer-container"`,
+					"example.com/anything-else":                         "customer-annotation",
+				},
+			},
+			secrets:     []string{"alice-binding", "acme-webhook", "customer-label", "customer-container", "customer-annotation"},
+			annotations: []string{"authorization.k8s.io/decision", "apiserver.latency.k8s.io/etcd"},
+		},
+		{
+			name: "bodies",
+			event: &auditv1.Event{
+				Level:          auditv1.LevelRequestResponse,
+				RequestObject:  &runtime.Unknown{Raw: []byte(`{"data":{"password":"hunter2"}}`)},
+				ResponseObject: &runtime.Unknown{Raw: []byte(`{"data":{"password":"hunter2"}}`)},
+			},
+			secrets: []string{"hunter2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			redacted := newAuditRedactor("salt").Redact(test.event)
+			content, err := json.Marshal(redacted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, secret := range test.secrets {
+				if strings.Contains(string(content), secret) {
+					t.Errorf("%q survived the redaction: %s", secret, content)
+				}
+			}
+			for _, annotation := range test.annotations {
+				if redacted.Annotations[annotation] != test.event.Annotations[annotation] {
+					t.Errorf("expected the annotation %q to be kept: %s", annotation, content)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactRequestURI(t *testing.T) {
+	r := newAuditRedactor("salt")
+	ns, name := r.namespace("foo"), r.name("bar")
+	tests := []struct {
+		uri      string
+		expected string
+	}{
+		{uri: "/api/v1/namespaces/foo/pods/bar", expected: "/api/v1/namespaces/" + ns + "/pods/" + name},
+		{uri: "/api/v1/namespaces/foo/pods/bar/log", expected: "/api/v1/namespaces/" + ns + "/pods/" + name + "/log"},
+		{uri: "/apis/apps/v1/namespaces/foo/deployments", expected: "/apis/apps/v1/namespaces/" + ns + "/deployments"},
+		{uri: "/api/v1/nodes/bar/status", expected: "/api/v1/nodes/" + name + "/status"},
+		{uri: "/apis/rbac.authorization.k8s.io/v1/clusterroles/bar", expected: "/apis/rbac.authorization.k8s.io/v1/clusterroles/" + name},
+		{uri: "/api/v1/namespaces/foo", expected: "/api/v1/namespaces/" + ns},
+		{uri: "/api/v1/namespaces/foo/finalize", expected: "/api/v1/namespaces/" + ns + "/finalize"},
+		{uri: "/api/v1/namespaces/openshift-etcd/pods/bar", expected: "/api/v1/namespaces/openshift-etcd/pods/" + name},
+		{
+			uri:      "/api/v1/namespaces/foo/pods?fieldSelector=spec.nodeName%3Dbar&labelSelector=app%3Dbar&limit=500",
+			expected: "/api/v1/namespaces/" + ns + "/pods?fieldSelector=" + r.hash("selector", "spec.nodeName=bar") + "&labelSelector=" + r.hash("selector", "app=bar") + "&limit=500",
+		},
+		{uri: "/healthz", expected: "/healthz"},
+	}
+	for _, test := range tests {
+		t.Run(test.uri, func(t *testing.T) {
+			if actual := r.requestURI(test.uri); actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRedactStatusMessage(t *testing.T) {
+	r := newAuditRedactor("salt")
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{
+			message:  `secrets "db-password" is forbidden`,
+			expected: `secrets "` + r.name("db-password") + `" is forbidden`,
+		},
+		{
+			message:  `namespaces "customer-ns" not found`,
+			expected: `namespaces "` + r.namespace("customer-ns") + `" not found`,
+		},
+		{
+			message:  `User "alice" cannot get resource "pods" in API group "apps" in the namespace "openshift-etcd"`,
+			expected: `User "` + r.username("alice") + `" cannot get resource "pods" in API group "apps" in the namespace "openshift-etcd"`,
+		},
+		{
+			message:  `pods "bar" is forbidden: User "system:serviceaccount:customer-ns:builder" cannot create resource "pods" in API group "" in the namespace "customer-ns"`,
+			expected: `pods "` + r.name("bar") + `" is forbidden: User "` + r.username("system:serviceaccount:customer-ns:builder") + `" cannot create resource "pods" in API group "" in the namespace "` + r.namespace("customer-ns") + `"`,
+		},
+		{
+			message:  `Internal error occurred: failed calling webhook "acme.example.com": dial tcp 10.0.0.5:443: connect: connection refused`,
+			expected: `Internal error occurred: failed calling webhook "` + r.name("acme.example.com") + `": dial tcp ` + r.hash("ip", "10.0.0.5") + `:443: connect: connection refused`,
+		},
+		{
+			message:  `dial tcp [fd00::5]:443: i/o timeout after 10:00:05`,
+			expected: `dial tcp [` + r.hash("ip", "fd00::5") + `]:443: i/o timeout after 10:00:05`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.message, func(t *testing.T) {
+			if actual := r.statusMessage(test.message); actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
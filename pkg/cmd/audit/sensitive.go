@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+)
+
+func PrintSensitiveAccessReport(writer io.Writer, accesses []*audit.SensitiveAccess) {
+	w := tabwriter.NewWriter(writer, 20, 0, 0, ' ', tabwriter.DiscardEmptyColumns)
+	defer w.Flush()
+
+	for _, access := range accesses {
+		marker := " "
+		if access.Human {
+			marker = "!"
+		}
+		fmt.Fprintf(w, "%s %8s [%s]\t %s\t %s\t [%s] %s\n",
+			marker,
+			fmt.Sprintf("%dx", access.Count),
+			statusCodesString(access.StatusCodeToCount),
+			access.Access,
+			access.Namespace,
+			access.User,
+			strings.Join(truncateList(access.Names.List(), 5), ","))
+	}
+}
+
+func PrintSensitiveAccessReportCSV(writer io.Writer, accesses []*audit.SensitiveAccess) error {
+	w := csv.NewWriter(writer)
+	if err := w.Write([]string{"human", "user", "namespace", "access", "count", "statusCodes", "first", "last", "names"}); err != nil {
+		return err
+	}
+	for _, access := range accesses {
+		if err := w.Write([]string{
+			strconv.FormatBool(access.Human),
+			access.User,
+			access.Namespace,
+			access.Access,
+			strconv.Itoa(access.Count),
+			statusCodesString(access.StatusCodeToCount),
+			access.First.UTC().Format(time.RFC3339),
+			access.Last.UTC().Format(time.RFC3339),
+			strings.Join(access.Names.List(), " "),
+		}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// truncateList keeps the first n items and notes how many were left out.
+func truncateList(items []string, n int) []string {
+	if len(items) <= n {
+		return items
+	}
+	return append(append([]string{}, items[:n]...), fmt.Sprintf("(and %d more)", len(items)-n))
+}
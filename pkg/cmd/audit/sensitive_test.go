@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+)
+
+func TestPrintSensitiveAccessReportCSV(t *testing.T) {
+	first := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	accesses := []*audit.SensitiveAccess{
+		{
+			User:              "alice",
+			Namespace:         "foo",
+			Access:            "secrets get",
+			Human:             true,
+			Count:             2,
+			Names:             sets.NewString("b", "a"),
+			StatusCodeToCount: map[int32]int{200: 2},
+			First:             first,
+			Last:              first.Add(time.Minute),
+		},
+	}
+
+	out := &bytes.Buffer{}
+	if err := PrintSensitiveAccessReportCSV(out, accesses); err != nil {
+		t.Fatal(err)
+	}
+	expected := "human,user,namespace,access,count,statusCodes,first,last,names\n" +
+		"true,alice,foo,secrets get,2," + statusCodesString(map[int32]int{200: 2}) + ",2026-10-18T10:00:00Z,2026-10-18T10:01:00Z,a b\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
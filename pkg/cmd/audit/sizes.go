@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+)
+
+// objectName formats an object as namespace/name, the namespace is omitted for cluster scoped objects.
+func objectName(namespace, name string) string {
+	if len(namespace) == 0 {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+func PrintSizeReport(writer io.Writer, numToDisplay int, report *audit.SizeReport) {
+	if report.EventsWithBodies == 0 {
+		fmt.Fprintln(writer, "no request or response bodies, the audit policy must log at the Request or RequestResponse level")
+		return
+	}
+
+	stats := report.Stats
+	if len(stats) > numToDisplay {
+		stats = stats[:numToDisplay]
+	}
+
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "REQUESTS\tREQUEST BYTES\tMAX REQUEST\tRESPONSE BYTES\tMAX RESPONSE\tVERB\tRESOURCE\tUSER\n")
+	for _, current := range stats {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			current.Count,
+			formatBytes(current.RequestBytes),
+			formatBytes(current.MaxRequestBytes),
+			formatBytes(current.ResponseBytes),
+			formatBytes(current.MaxResponseBytes),
+			strings.ToUpper(current.Verb),
+			current.Resource.String(),
+			current.User)
+	}
+	w.Flush()
+
+	if len(report.LargestWrites) > 0 {
+		fmt.Fprintf(writer, "\nLargest objects written (etcd rejects requests over %s):\n", formatBytes(audit.EtcdRequestSizeLimit))
+		w = tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "  SIZE\tETCD LIMIT\tTIME\tVERB\tRESOURCE\tOBJECT\tUSER\n")
+		for _, object := range report.LargestWrites {
+			limit := float64(object.Bytes) / audit.EtcdRequestSizeLimit
+			marker := " "
+			if limit >= 0.75 {
+				marker = "!"
+			}
+			fmt.Fprintf(w, "%s %s\t%.0f%%\t%s\t%s\t%s\t%s\t%s\n",
+				marker,
+				formatBytes(object.Bytes),
+				limit*100,
+				object.Event.RequestReceivedTimestamp.UTC().Format("15:04:05"),
+				strings.ToUpper(object.Event.Verb),
+				object.Resource.String(),
+				objectName(object.Namespace, object.Name),
+				object.Event.User.Username)
+		}
+		w.Flush()
+	}
+
+	if len(report.LargestLists) > 0 {
+		fmt.Fprintf(writer, "\nLargest list responses:\n")
+		w = tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "SIZE\tITEMS\tLATENCY\tTIME\tURI\tUSER\n")
+		for _, list := range report.LargestLists {
+			items := "-"
+			if list.Items >= 0 {
+				items = fmt.Sprintf("%d", list.Items)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%v\t%s\t%s\t%s\n",
+				formatBytes(list.Bytes),
+				items,
+				audit.Latency(list.Event).Round(time.Millisecond),
+				list.Event.RequestReceivedTimestamp.UTC().Format("15:04:05"),
+				list.Event.RequestURI,
+				list.Event.User.Username)
+		}
+		w.Flush()
+	}
+}
@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+)
+
+// perHour formats a count as a rate over the span, there is no rate for a span shorter than a minute.
+func perHour(count int, span time.Duration) string {
+	if span < time.Minute {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", float64(count)/span.Hours())
+}
+
+func PrintWatchReport(writer io.Writer, numToDisplay int, report *audit.WatchReport, shortLived time.Duration) {
+	stats := report.Stats
+	if len(stats) > numToDisplay {
+		stats = stats[:numToDisplay]
+	}
+
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintf(w, "  WATCHES\tWATCHES/H\tMIN\tMEDIAN\tP90\tMAX\tSHORT LIVED (<%v)\tEARLY\tFROM SCRATCH\tLISTS/H\tRESOURCE\tUSER\n", shortLived)
+	for _, current := range stats {
+		marker := " "
+		if current.ShortLived > 0 {
+			marker = "!"
+		}
+		fmt.Fprintf(w, "%s %d\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\t%s\n",
+			marker,
+			current.Watches,
+			perHour(current.Watches, report.Span),
+			formatLifespan(current.Durations, 0),
+			formatLifespan(current.Durations, 50),
+			formatLifespan(current.Durations, 90),
+			formatLifespan(current.Durations, 100),
+			current.ShortLived,
+			current.Early,
+			current.FromScratch,
+			perHour(current.Lists, report.Span),
+			current.Resource.String(),
+			current.User)
+	}
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+)
+
+func PrintWebhookImpactReport(writer io.Writer, webhooks []*audit.WebhookImpact) {
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "WEBHOOK\tTYPE\tCONFIGURATION\tINVOCATIONS\tMUTATED\tP50\tP90\tP99\tDENIED\tCALL FAILURES\tFAILED OPEN\tRESOURCES\n")
+	for _, webhook := range webhooks {
+		configuration := webhook.Configuration
+		if len(configuration) == 0 {
+			configuration = "<unknown>"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%v\t%v\t%v\t%d\t%d\t%d\t%s\n",
+			webhook.Name,
+			webhook.Type,
+			configuration,
+			webhook.Invocations,
+			webhook.Mutations,
+			audit.LatencyPercentile(50, webhook.Latencies),
+			audit.LatencyPercentile(90, webhook.Latencies),
+			audit.LatencyPercentile(99, webhook.Latencies),
+			webhook.Denials,
+			webhook.CallFailures,
+			webhook.FailedOpen,
+			strings.Join(truncateList(webhook.Resources.List(), 5), ","))
+	}
+	w.Flush()
+
+	for _, webhook := range webhooks {
+		if len(webhook.Patches) == 0 && len(webhook.FailedAuditIDs) == 0 {
+			continue
+		}
+		fmt.Fprintf(writer, "\n%s:\n", webhook.Name)
+		patches := []string{}
+		for patch := range webhook.Patches {
+			patches = append(patches, patch)
+		}
+		sort.Slice(patches, func(i, j int) bool {
+			if webhook.Patches[patches[i]] != webhook.Patches[patches[j]] {
+				return webhook.Patches[patches[i]] > webhook.Patches[patches[j]]
+			}
+			return patches[i] < patches[j]
+		})
+		for _, patch := range patches {
+			fmt.Fprintf(writer, "  patch %dx %s\n", webhook.Patches[patch], patch)
+		}
+		for _, auditID := range webhook.FailedAuditIDs {
+			fmt.Fprintf(writer, "  failed %s\n", auditID)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package events
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// generatedSuffix is the alphabet of the random suffixes of generated names, it has no vowels so that words are not
+// mistaken for suffixes.
+const generatedSuffix = `[bcdfghjklmnpqrstvwxz2456789]`
+
+// the masks are applied in order, UIDs, hashes and IPs before the numbers they contain.
+var eventMessageMasks = []struct {
+	regex       *regexp.Regexp
+	replacement string
+}{
+	{regex: regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), replacement: "<uid>"},
+	{regex: regexp.MustCompile(`sha256:[0-9a-f]{64}`), replacement: "<hash>"},
+	{regex: regexp.MustCompile(`\b[0-9a-f]{16,}\b`), replacement: "<hash>"},
+	{regex: regexp.MustCompile(`\b[0-9]{1,3}(\.[0-9]{1,3}){3}(:[0-9]+)?\b`), replacement: "<ip>"},
+	{regex: regexp.MustCompile(`\[[0-9a-fA-F:]*:[0-9a-fA-F:]*\](:[0-9]+)?`), replacement: "<ip>"},
+	// the pods of a deployment are <name>-<pod template hash>-<suffix>, the others <name>-<suffix>
+	{regex: regexp.MustCompile(`-` + generatedSuffix + `{6,10}-` + generatedSuffix + `{5}\b`), replacement: "-<pod>"},
+	{regex: regexp.MustCompile(`-` + generatedSuffix + `{5}\b`), replacement: "-<pod>"},
+	{regex: regexp.MustCompile(`[0-9]+(\.[0-9]+)*`), replacement: "<n>"},
+}
+
+// EventCluster groups the events about the same kind with the same reason and message template.
+type EventCluster struct {
+	Kind   string
+	Reason string
+	// Template is the message with the involved object, UIDs, hashes, IPs, generated pod names and numbers masked.
+	Template string
+	Type     string
+	// Count is the number of times the events of the cluster were seen, the sum of their counts.
+	Count      int32
+	Events     int
+	First      time.Time
+	Last       time.Time
+	Namespaces sets.String
+	Example    *corev1.Event
+}
+
+// templateEventMessage masks the parts of the message of an event that differ between events with the same cause, eg.
+// `Successfully assigned foo/bar-7d4b9c8f5-x2x4k to 10.0.0.5` becomes `Successfully assigned <namespace>/<name> to <ip>`.
+func templateEventMessage(event *corev1.Event) string {
+	message := event.Message
+	// the involved object is the most common variable part.  The namespace is only masked as <namespace>/, a namespace
+	// named like a word would otherwise mask that word, and the name as a whole word so that short names do not mask
+	// parts of words.
+	if len(event.InvolvedObject.Namespace) > 0 {
+		message = replaceWord(message, event.InvolvedObject.Namespace+"/", "<namespace>/")
+	}
+	message = replaceWord(message, event.InvolvedObject.Name, "<name>")
+
+	for _, mask := range eventMessageMasks {
+		message = mask.regex.ReplaceAllString(message, mask.replacement)
+	}
+	return message
+}
+
+// replaceWord replaces the occurrences of word in message that are not part of a longer name, ie. that are neither
+// preceded nor followed by a letter, a digit, a dot or a dash.  A word ending with a slash may be followed by anything.
+func replaceWord(message, word, replacement string) string {
+	if len(word) == 0 {
+		return message
+	}
+	ret := strings.Builder{}
+	for {
+		i := strings.Index(message, word)
+		if i < 0 {
+			break
+		}
+		end := i + len(word)
+		if (i > 0 && isNameByte(message[i-1])) || (end < len(message) && isNameByte(message[end]) && !strings.HasSuffix(word, "/")) {
+			ret.WriteString(message[:i+1])
+			message = message[i+1:]
+			continue
+		}
+		ret.WriteString(message[:i])
+		ret.WriteString(replacement)
+		message = message[end:]
+	}
+	ret.WriteString(message)
+	return ret.String()
+}
+
+func isNameByte(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '.' || c == '-'
+}
+
+// ClusterEvents groups the events by involved kind, reason and message template.  Clusters are sorted by count.
+func ClusterEvents(events []*corev1.Event) []*EventCluster {
+	clusters := map[string]*EventCluster{}
+	for _, event := range events {
+		template := templateEventMessage(event)
+		key := event.InvolvedObject.Kind + "/" + event.Reason + "/" + template
+
+		cluster, ok := clusters[key]
+		if !ok {
+			cluster = &EventCluster{
+				Kind:       event.InvolvedObject.Kind,
+				Reason:     event.Reason,
+				Template:   template,
+				Type:       event.Type,
+				Namespaces: sets.NewString(),
+				First:      event.FirstTimestamp.Time,
+				Last:       event.LastTimestamp.Time,
+				Example:    event,
+			}
+			clusters[key] = cluster
+		}
+
+		count := event.Count
+		if count < 1 {
+			count = 1
+		}
+		cluster.Count += count
+		cluster.Events++
+		if len(event.InvolvedObject.Namespace) > 0 {
+			cluster.Namespaces.Insert(event.InvolvedObject.Namespace)
+		}
+		if event.FirstTimestamp.Time.Before(cluster.First) {
+			cluster.First = event.FirstTimestamp.Time
+		}
+		if event.LastTimestamp.Time.After(cluster.Last) {
+			cluster.Last = event.LastTimestamp.Time
+			// the latest event is the most relevant example
+			cluster.Example = event
+		}
+	}
+
+	ret := []*EventCluster{}
+	for _, cluster := range clusters {
+		ret = append(ret, cluster)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Count != ret[j].Count {
+			return ret[i].Count > ret[j].Count
+		}
+		if ret[i].Reason != ret[j].Reason {
+			return ret[i].Reason < ret[j].Reason
+		}
+		return ret[i].Template < ret[j].Template
+	})
+	return ret
+}
+
+func PrintEventClusters(writer io.Writer, clusters []*EventCluster) error {
+	events, count := 0, int32(0)
+	for _, cluster := range clusters {
+		events += cluster.Events
+		count += cluster.Count
+	}
+	if _, err := fmt.Fprintf(writer, "%d events seen %d times in %d clusters\n", events, count, len(clusters)); err != nil {
+		return err
+	}
+
+	for _, cluster := range clusters {
+		fmt.Fprintf(writer, "\n%dx [%s %s %s] %s\n", cluster.Count, cluster.Type, cluster.Kind, cluster.Reason, cluster.Template)
+
+		namespaces := cluster.Namespaces.List()
+		if len(namespaces) > 5 {
+			namespaces = append(namespaces[:5], fmt.Sprintf("(%d more)", cluster.Namespaces.Len()-5))
+		}
+		example := cluster.Example.InvolvedObject.Name
+		if len(cluster.Example.InvolvedObject.Namespace) > 0 {
+			example = cluster.Example.InvolvedObject.Namespace + "/" + example
+		}
+
+		w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "  between\t%s - %s\n", cluster.First.UTC().Format(time.RFC3339), cluster.Last.UTC().Format(time.RFC3339))
+		fmt.Fprintf(w, "  events\t%d\n", cluster.Events)
+		fmt.Fprintf(w, "  namespaces\t%s\n", strings.Join(namespaces, ", "))
+		fmt.Fprintf(w, "  example\t%s: %s\n", example, strings.ReplaceAll(cluster.Example.Message, "\n", " "))
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestTemplateEventMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    *corev1.Event
+		expected string
+	}{
+		{
+			name: "involved object and ip",
+			event: &corev1.Event{
+				InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "openshift-etcd", Name: "etcd-quorum-guard-7d4b9c8f5-x2x4k"},
+				Message:        "Successfully assigned openshift-etcd/etcd-quorum-guard-7d4b9c8f5-x2x4k to 10.0.0.5",
+			},
+			expected: "Successfully assigned <namespace>/<name> to <ip>",
+		},
+		{
+			name: "other pods, ports and numbers",
+			event: &corev1.Event{
+				InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "foo", Name: "bar"},
+				Message:        `Readiness probe failed: Get "https://10.128.0.12:8443/healthz": dial tcp 10.128.0.12:8443: connect: connection refused, 3 of router-default-5cd8f9b6d4-kq2zp failed`,
+			},
+			expected: `Readiness probe failed: Get "https://<ip>/healthz": dial tcp <ip>: connect: connection refused, <n> of router-default-<pod> failed`,
+		},
+		{
+			name: "uids and hashes",
+			event: &corev1.Event{
+				InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "foo", Name: "bar"},
+				Message:        "Pulling image quay.io/openshift/etcd@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef for pod 1f2e3d4c-5b6a-4789-9abc-def012345678",
+			},
+			expected: "Pulling image quay.io/openshift/etcd@<hash> for pod <uid>",
+		},
+		{
+			name: "short names only as whole words",
+			event: &corev1.Event{
+				InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "a", Name: "db"},
+				Message:        "Stopping container db in a/db, a database-db-1 pod",
+			},
+			expected: "Stopping container <name> in <namespace>/<name>, a database-db-<n> pod",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := templateEventMessage(test.event); actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestClusterEvents(t *testing.T) {
+	events := []*corev1.Event{
+		{InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "a", Name: "foo-abcde"}, Reason: "BackOff", Message: "Back-off restarting failed container", Count: 3},
+		{InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "b", Name: "bar"}, Reason: "BackOff", Message: "Back-off restarting failed container", Count: 4},
+		{InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "a", Name: "foo-abcde"}, Reason: "Pulled", Message: "Container image pulled"},
+	}
+	clusters := ClusterEvents(events)
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %d", len(clusters))
+	}
+	if clusters[0].Reason != "BackOff" || clusters[0].Count != 7 || clusters[0].Events != 2 || clusters[0].Namespaces.Len() != 2 {
+		t.Errorf("unexpected first cluster %#v", clusters[0])
+	}
+	if clusters[1].Reason != "Pulled" || clusters[1].Count != 1 {
+		t.Errorf("unexpected second cluster %#v", clusters[1])
+	}
+}
@@ -1,10 +1,6 @@
 package events
 
 import (
-	"fmt"
-	"os"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/openshift/cluster-debug-tools/pkg/util"
@@ -98,42 +94,20 @@ func (f *FilterByReasons) FilterEvents(events ...*corev1.Event) []*corev1.Event
 	return ret
 }
 
-type FilterByAround struct {
-	Around         string
-	AroundDuration time.Duration
+// FilterByTimeWindow keeps the events last seen within the window, a zero time leaves that side open.
+type FilterByTimeWindow struct {
+	After  time.Time
+	Before time.Time
 }
 
-func (f *FilterByAround) FilterEvents(events ...*corev1.Event) []*corev1.Event {
-	t := events[len(events)-1].LastTimestamp.Time
-	aroundParts := strings.Split(f.Around, ":")
-	if len(aroundParts) < 2 || len(aroundParts) > 3 {
-		fmt.Fprintf(os.Stderr, "invalid around time format, must be HH:MM or HH:MM:SS, got %q", f.Around)
-		return nil
-	}
-	aroundTimeHours, err := strconv.Atoi(aroundParts[0])
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error parsing around time: %b", err)
-		return nil
-	}
-	aroundTimeMinutes, err := strconv.Atoi(aroundParts[1])
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error parsing around time: %b", err)
-		return nil
-	}
-	aroundTimeSeconds := 0
-	if len(aroundParts) > 2 {
-		aroundTimeSeconds, err = strconv.Atoi(aroundParts[2])
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error parsing around time: %b", err)
-			return nil
-		}
-	}
-
-	aroundTime := time.Date(t.Year(), t.Month(), t.Day(), aroundTimeHours, aroundTimeMinutes, aroundTimeSeconds, t.Nanosecond(), t.Location())
+func (f *FilterByTimeWindow) FilterEvents(events ...*corev1.Event) []*corev1.Event {
 	ret := []*corev1.Event{}
 	for i := range events {
 		event := events[i]
-		if event.LastTimestamp.Time.After(aroundTime.Add(f.AroundDuration)) || event.LastTimestamp.Time.Before(aroundTime.Add(-f.AroundDuration)) {
+		if !f.After.IsZero() && event.LastTimestamp.Time.Before(f.After) {
+			continue
+		}
+		if !f.Before.IsZero() && event.LastTimestamp.Time.After(f.Before) {
 			continue
 		}
 		ret = append(ret, event)
@@ -231,3 +205,25 @@ func (f *FilterByKind) FilterEvents(events ...*corev1.Event) []*corev1.Event {
 
 	return ret
 }
+
+// FilterByInvolvedObjects keeps the events about the objects, eg. the objects of an ownership chain.
+type FilterByInvolvedObjects struct {
+	Objects []corev1.ObjectReference
+}
+
+func (f *FilterByInvolvedObjects) FilterEvents(events ...*corev1.Event) []*corev1.Event {
+	keys := sets.NewString()
+	for _, obj := range f.Objects {
+		keys.Insert(objectKey(obj.Kind, obj.Namespace, obj.Name))
+	}
+
+	ret := []*corev1.Event{}
+	for i := range events {
+		event := events[i]
+		if keys.Has(objectKey(event.InvolvedObject.Kind, event.InvolvedObject.Namespace, event.InvolvedObject.Name)) {
+			ret = append(ret, event)
+		}
+	}
+
+	return ret
+}
@@ -5,9 +5,6 @@ import (
 	"fmt"
 	"sort"
 	"strings"
-	"time"
-
-	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/spf13/cobra"
 
@@ -15,7 +12,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	"k8s.io/cli-runtime/pkg/resource"
+	utilpointer "k8s.io/utils/pointer"
+
+	"github.com/openshift/cluster-debug-tools/pkg/util"
 )
 
 var (
@@ -31,42 +30,58 @@ var (
 
 	# find CREATEs of everything except SAR and tokenreview
 	%[1]s event -f event.json --verb=create --resource=*.* --resource=-subjectaccessreviews.* --resource=-tokenreviews.*
+
+	# read every events.yaml of a must-gather, or the gzipped events of a CI run
+	%[1]s event -f must-gather/ --warning-only
+	%[1]s event -f artifacts/gather-extra/events.json.gz
+
+	# display the events five minutes around 10:12 UTC, or in the last ten minutes
+	%[1]s event -f event.json --around=10:12 --window=5m
+	%[1]s event -f event.json --after=end-10m
+
+	# group the events of a CI run by kind, reason and message template to find the few real signals
+	%[1]s event -f artifacts/gather-extra/events.json --output=clusters
+
+	# display the events of a deployment, its replica sets and pods, and the claims and nodes of the pods in one
+	# timeline, from a must-gather or from the cluster of the current context
+	%[1]s event -f must-gather/ --for=deployment/foo -n bar
+	%[1]s event --for=deployment/foo -n bar
+
+	# run the saved query of the team for the warnings of the operators, see query list
+	%[1]s event -f event.json --query=operator-warnings
 `
 )
 
 type EventOptions struct {
-	configFlags  *genericclioptions.ConfigFlags
-	builderFlags *genericclioptions.ResourceBuilderFlags
-
-	kinds          []string
-	namespaces     []string
-	names          []string
-	reasons        []string
-	components     []string
-	uids           []string
-	filename       string
-	warningOnly    bool
-	output         string
-	sortBy         string
-	around         string
-	aroundDuration time.Duration
+	filenames []string
+	recursive bool
+
+	kinds       []string
+	namespaces  []string
+	names       []string
+	reasons     []string
+	components  []string
+	uids        []string
+	warningOnly bool
+	output      string
+	sortBy      string
+	query       string
+	timeWindow  *util.TimeWindowOptions
+
+	// forObject is the kind/name of the root of the ownership chain to display the events of.
+	forObject   string
+	configFlags *genericclioptions.ConfigFlags
 
 	genericclioptions.IOStreams
 }
 
 func NewEventOptions(streams genericclioptions.IOStreams) *EventOptions {
-	configFlags := genericclioptions.NewConfigFlags(true)
-	configFlags.Namespace = nil
-
-	scheme := runtime.NewScheme()
-	if err := corev1.AddToScheme(scheme); err != nil {
-		panic(err)
-	}
-
 	return &EventOptions{
-		configFlags: configFlags,
-		builderFlags: genericclioptions.NewResourceBuilderFlags().
-			WithLocal(true).WithScheme(scheme).WithAllNamespaces(true).WithLatest().WithAll(true),
+		timeWindow: util.NewTimeWindowOptions(),
+		configFlags: &genericclioptions.ConfigFlags{
+			KubeConfig: utilpointer.String(""),
+			Context:    utilpointer.String(""),
+		},
 
 		IOStreams: streams,
 	}
@@ -95,7 +110,10 @@ func NewCmdEvent(parentName string, streams genericclioptions.IOStreams) *cobra.
 		},
 	}
 
-	cmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Choose your output format")
+	cmd.Flags().StringSliceVarP(&o.filenames, "filename", "f", o.filenames, "Event files or directories, eg. a must-gather. Directories are read recursively, .gz files are decompressed and the objects that are not events are skipped.")
+	cmd.Flags().BoolVarP(&o.recursive, "recursive", "R", o.recursive, "Process the directory used in -f, --filename recursively.")
+	cmd.Flags().MarkDeprecated("recursive", "directories are always read recursively")
+	cmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Choose your output format: wide, json, components, clusters")
 	cmd.Flags().StringSliceVar(&o.uids, "uid", o.uids, "Only match specific UIDs")
 	cmd.Flags().StringSliceVar(&o.kinds, "kinds", o.kinds, "Filter result of search to only contain the specified kind.)")
 	cmd.Flags().StringSliceVarP(&o.namespaces, "namespace", "n", o.namespaces, "Filter result of search to only contain the specified namespace.)")
@@ -104,57 +122,111 @@ func NewCmdEvent(parentName string, streams genericclioptions.IOStreams) *cobra.
 	cmd.Flags().StringSliceVar(&o.components, "component", o.components, "Filter result of search to only contain the specified component.)")
 	cmd.Flags().BoolVar(&o.warningOnly, "warning-only", false, "Filter result of search to only contain http failures.)")
 	cmd.Flags().StringVar(&o.sortBy, "by", o.sortBy, "Choose how to sort")
-	cmd.Flags().StringVar(&o.around, "around", o.around, "Display only events around specified time (format: hh:mm or hh:mm:ss)")
-	cmd.Flags().DurationVar(&o.aroundDuration, "around-duration", 10*time.Minute, "Change the time duration to display events around time")
-
+	o.timeWindow.AddFlags(cmd.Flags())
+	cmd.Flags().DurationVar(&o.timeWindow.Window, "around-duration", o.timeWindow.Window, "Change the time duration to display events around time")
+	cmd.Flags().MarkDeprecated("around-duration", "use --window instead")
+	util.AddQueryFlag(cmd.Flags(), &o.query)
+	cmd.Flags().StringVar(&o.forObject, "for", o.forObject, "Only display the events of an object and of the objects it owns, eg. deployment/foo: its replica sets, their pods and the persistent volume claims and the nodes of the pods. The owners are resolved from the objects of -f or, without -f, from the cluster of --kubeconfig.")
 	o.configFlags.AddFlags(cmd.Flags())
-	o.builderFlags.AddFlags(cmd.Flags())
 
 	return cmd
 }
 
 func (o *EventOptions) Complete(command *cobra.Command, args []string) error {
-
-	return nil
+	return util.ApplyQuery(command, o.query)
 }
 
 func (o *EventOptions) Validate() error {
-	return nil
+	if len(o.filenames) == 0 && len(o.forObject) == 0 {
+		return fmt.Errorf("-f is required")
+	}
+	if len(o.forObject) > 0 {
+		if len(o.namespaces) > 1 {
+			return fmt.Errorf("--for requires a single namespace")
+		}
+		if _, err := ParseForObject(o.forObject, o.forNamespace()); err != nil {
+			return fmt.Errorf("invalid --for: %w", err)
+		}
+	}
+	return o.timeWindow.Validate()
 }
 
-func (o *EventOptions) Run() error {
-	events := []*corev1.Event{}
+// forNamespace is the namespace of the root of the ownership chain.
+func (o *EventOptions) forNamespace() string {
+	if len(o.namespaces) == 0 {
+		return ""
+	}
+	return o.namespaces[0]
+}
 
-	visitor := o.builderFlags.ToBuilder(o.configFlags, nil).Do()
-	err := visitor.Visit(func(info *resource.Info, err error) error {
+// readOwnedEvents reads the events and the objects that can be part of the ownership chain of --for and returns the
+// events with the objects of the chain.
+func (o *EventOptions) readOwnedEvents() ([]*corev1.Event, []corev1.ObjectReference, error) {
+	root, err := ParseForObject(o.forObject, o.forNamespace())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(o.filenames) > 0 {
+		events, resources, err := ReadEventAndResourceFiles(o.filenames...)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
+		return events, OwnedObjects(root, resources), nil
+	}
 
-		switch castObj := info.Object.(type) {
-		case *corev1.Event:
-			event := info.Object.(*corev1.Event)
-			events = append(events, event)
+	events, resources, err := ReadClusterEventsAndResources(o.configFlags, root.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	objects := OwnedObjects(root, resources)
+	nodes := []string{}
+	for _, obj := range objects {
+		if obj.Kind == "Node" {
+			nodes = append(nodes, obj.Name)
+		}
+	}
+	nodeEvents, err := ReadClusterNodeEvents(o.configFlags, nodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(events, nodeEvents...), objects, nil
+}
 
-			// inject the event twice when it appeared multiple times for easy sorting/reading
-			if event.LastTimestamp != event.FirstTimestamp {
-				alternateEvent := event.DeepCopy()
-				alternateEvent.FirstTimestamp = event.LastTimestamp
-				events = append(events, alternateEvent)
-			}
-		default:
-			return fmt.Errorf("unhandled resource: %T", castObj)
+func (o *EventOptions) Run() error {
+	var events []*corev1.Event
+	var ownedObjects []corev1.ObjectReference
+	var err error
+	if len(o.forObject) > 0 {
+		events, ownedObjects, err = o.readOwnedEvents()
+	} else {
+		events, err = ReadEventFiles(o.filenames...)
+	}
+	if err != nil {
+		return err
+	}
+	// inject the event twice when it appeared multiple times for easy sorting/reading, the clusters count every event
+	// once
+	for _, event := range events {
+		if o.output != "clusters" && event.LastTimestamp != event.FirstTimestamp {
+			alternateEvent := event.DeepCopy()
+			alternateEvent.FirstTimestamp = event.LastTimestamp
+			events = append(events, alternateEvent)
 		}
+	}
 
-		return nil
-	})
+	span := util.TimeSpan{}
+	for _, event := range events {
+		span.Include(event.LastTimestamp.Time)
+	}
+	after, before, err := o.timeWindow.ToTimeWindow(span)
 	if err != nil {
 		return err
 	}
 
 	filters := EventFilters{}
-	if len(o.around) > 0 {
-		filters = append(filters, &FilterByAround{Around: o.around, AroundDuration: o.aroundDuration})
+	if !after.IsZero() || !before.IsZero() {
+		filters = append(filters, &FilterByTimeWindow{After: after, Before: before})
 	}
 	if len(o.uids) > 0 {
 		filters = append(filters, &FilterByUIDs{UIDs: sets.NewString(o.uids...)})
@@ -165,7 +237,10 @@ func (o *EventOptions) Run() error {
 	if len(o.names) > 0 {
 		filters = append(filters, &FilterByNames{Names: sets.NewString(o.names...)})
 	}
-	if len(o.namespaces) > 0 {
+	if len(ownedObjects) > 0 {
+		// the namespace is the one of the root, the nodes of the chain have none
+		filters = append(filters, &FilterByInvolvedObjects{Objects: ownedObjects})
+	} else if len(o.namespaces) > 0 {
 		filters = append(filters, &FilterByNamespaces{Namespaces: sets.NewString(o.namespaces...)})
 	}
 	if len(o.kinds) > 0 {
@@ -201,9 +276,17 @@ func (o *EventOptions) Run() error {
 	switch o.output {
 	case "components":
 		PrintComponents(o.Out, events)
+	case "clusters":
+		return PrintEventClusters(o.Out, ClusterEvents(events))
 	case "":
+		if len(ownedObjects) > 0 {
+			return PrintObjectEvents(o.Out, events, false)
+		}
 		PrintEvents(o.Out, events)
 	case "wide":
+		if len(ownedObjects) > 0 {
+			return PrintObjectEvents(o.Out, events, true)
+		}
 		PrintEventsWide(o.Out, events)
 	case "json":
 		encoder := json.NewEncoder(o.Out)
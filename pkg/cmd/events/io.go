@@ -25,6 +25,21 @@ func PrintComponents(writer io.Writer, events []*corev1.Event) error {
 }
 
 func PrintEvents(writer io.Writer, events []*corev1.Event) error {
+	return printEvents(writer, events, false, false)
+}
+
+// PrintEventsWide also prints the file every event was read from.
+func PrintEventsWide(writer io.Writer, events []*corev1.Event) error {
+	return printEvents(writer, events, true, false)
+}
+
+// PrintObjectEvents prints the kind and the name of the involved object rather than its namespace, eg. for the events
+// of an ownership chain which are all in the same namespace.
+func PrintObjectEvents(writer io.Writer, events []*corev1.Event, wide bool) error {
+	return printEvents(writer, events, wide, true)
+}
+
+func printEvents(writer io.Writer, events []*corev1.Event, wide, objects bool) error {
 	for _, event := range events {
 		message := event.Message
 		message = strings.Replace(message, "\\\\", "\\", -1)
@@ -44,18 +59,22 @@ func PrintEvents(writer io.Writer, events []*corev1.Event) error {
 		if len(componentName) == 0 {
 			componentName = event.InvolvedObject.Name
 		}
-		if len(componentName) == 0 && len(event.ReportingController) > 0 || len(event.ReportingInstance) > 0 {
+		if objects {
+			componentName = event.InvolvedObject.Kind + "/" + event.InvolvedObject.Name
+		}
+		if len(componentName) == 0 && (len(event.ReportingController) > 0 || len(event.ReportingInstance) > 0) {
 			componentName = fmt.Sprintf("%s-%s", event.ReportingController, event.ReportingInstance)
 		}
 
-		if _, err := fmt.Fprintf(writer, "%s (%s) %q %s %s\n", event.LastTimestamp.Format("15:04:05"), countMessage, componentName, event.Reason, message); err != nil {
+		source := ""
+		if wide {
+			source = fmt.Sprintf(" [%s]", event.Annotations[SourceAnnotation])
+		}
+
+		if _, err := fmt.Fprintf(writer, "%s (%s) %q %s %s%s\n", event.LastTimestamp.UTC().Format("15:04:05"), countMessage, componentName, event.Reason, message, source); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
-
-func PrintEventsWide(writer io.Writer, events []*corev1.Event) error {
-	return PrintEvents(writer, events)
-}
@@ -0,0 +1,209 @@
+package events
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	eventsv1beta1 "k8s.io/api/events/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog"
+)
+
+// SourceAnnotation records the file an event was read from.
+const SourceAnnotation = "events.cluster-debug-tools.openshift.io/source"
+
+// ReadEventFiles reads the events of files and directories, eg. a whole must-gather with its namespaces/*/core/events.yaml
+// or the events.json.gz of a CI run.  Directories are walked recursively for .yaml, .yml and .json files, they can be
+// gzipped.  A file can hold several documents and lists, the objects that are not events are skipped.  The
+// events.k8s.io Events are converted to core/v1 Events and every event is annotated with its file.
+func ReadEventFiles(paths ...string) ([]*corev1.Event, error) {
+	events, _, err := readEventAndResourceFiles(paths, sets.NewString())
+	return events, err
+}
+
+// ReadEventAndResourceFiles reads the events like ReadEventFiles and also keeps the other objects of the files that
+// can be part of an ownership chain, eg. the deployments, replica sets, pods and persistent volume claims of a
+// must-gather.
+func ReadEventAndResourceFiles(paths ...string) ([]*corev1.Event, []*unstructured.Unstructured, error) {
+	return readEventAndResourceFiles(paths, ownerGraphKinds)
+}
+
+// readEventAndResourceFiles reads the events and the objects of the kinds to keep.
+func readEventAndResourceFiles(paths []string, kinds sets.String) ([]*corev1.Event, []*unstructured.Unstructured, error) {
+	events := []*corev1.Event{}
+	resources := []*unstructured.Unstructured{}
+	skipped := 0
+	err := readObjectFiles(paths, func(filename string, obj *unstructured.Unstructured) error {
+		event, err := toEvent(obj)
+		if err != nil {
+			return fmt.Errorf("unable to read %q: %w", filename, err)
+		}
+		if event == nil {
+			if kinds.Has(obj.GetKind()) {
+				resources = append(resources, obj)
+			} else {
+				skipped++
+			}
+			return nil
+		}
+		if event.Annotations == nil {
+			event.Annotations = map[string]string{}
+		}
+		event.Annotations[SourceAnnotation] = filename
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if skipped > 0 {
+		klog.V(2).Infof("skipped %d objects that are not events", skipped)
+	}
+	return events, resources, nil
+}
+
+// readObjectFiles calls visit for every object of the files, the lists are flattened.  The files found in directories
+// that cannot be decoded, eg. the JSON arrays of etcd_info/ in a must-gather, are skipped, the files given explicitly
+// must be decoded.
+func readObjectFiles(paths []string, visit func(filename string, obj *unstructured.Unstructured) error) error {
+	for _, path := range paths {
+		err := filepath.Walk(path, func(filename string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			// files given explicitly are read whatever their extension
+			if filename != path && !isEventFileName(filename) {
+				return nil
+			}
+			objects, err := readObjectFile(filename)
+			if err != nil {
+				if filename != path {
+					klog.V(2).Infof("skipped %q: %v", filename, err)
+					return nil
+				}
+				return err
+			}
+			for _, obj := range objects {
+				if err := visit(filename, obj); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isEventFileName(filename string) bool {
+	switch filepath.Ext(strings.TrimSuffix(filename, ".gz")) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// readObjectFile decodes the objects of a file, the lists are flattened.
+func readObjectFile(filename string) ([]*unstructured.Unstructured, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(filename, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %q: %w", filename, err)
+		}
+		defer zr.Close()
+		reader = zr
+	}
+
+	ret := []*unstructured.Unstructured{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(reader, 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("unable to read %q: %w", filename, err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		err := eachObject(obj, func(item *unstructured.Unstructured) error {
+			ret = append(ret, item)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %q: %w", filename, err)
+		}
+	}
+	return ret, nil
+}
+
+// eachObject calls fn for an object or for every item of a list, the items of a typed list like EventList may omit
+// their kind.
+func eachObject(obj *unstructured.Unstructured, fn func(*unstructured.Unstructured) error) error {
+	if !obj.IsList() {
+		return fn(obj)
+	}
+
+	itemGVK := obj.GroupVersionKind()
+	itemGVK.Kind = strings.TrimSuffix(itemGVK.Kind, "List")
+	return obj.EachListItem(func(item runtime.Object) error {
+		itemObj := item.(*unstructured.Unstructured)
+		if len(itemObj.GetKind()) == 0 {
+			itemObj.SetGroupVersionKind(itemGVK)
+		}
+		return eachObject(itemObj, fn)
+	})
+}
+
+var (
+	coreEventGVK          = corev1.SchemeGroupVersion.WithKind("Event")
+	eventsV1EventGVK      = eventsv1.SchemeGroupVersion.WithKind("Event")
+	eventsV1beta1EventGVK = eventsv1beta1.SchemeGroupVersion.WithKind("Event")
+)
+
+// toEvent converts an event of any API to a core/v1 Event, it returns nil for the other kinds.
+func toEvent(obj *unstructured.Unstructured) (*corev1.Event, error) {
+	switch obj.GroupVersionKind() {
+	case coreEventGVK:
+		event := &corev1.Event{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, event); err != nil {
+			return nil, err
+		}
+		return normalizeEvent(event), nil
+	case eventsV1EventGVK:
+		event := &eventsv1.Event{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, event); err != nil {
+			return nil, err
+		}
+		return eventsV1ToCore(event), nil
+	case eventsV1beta1EventGVK:
+		event := &eventsv1beta1.Event{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, event); err != nil {
+			return nil, err
+		}
+		return eventsV1beta1ToCore(event), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,90 @@
+package events
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadEventFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "namespaces", "foo", "core"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mixed := `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata: {name: bar, namespace: foo}
+- apiVersion: v1
+  kind: Event
+  metadata: {name: bar.1, namespace: foo}
+  involvedObject: {kind: Pod, name: bar, namespace: foo}
+  reason: BackOff
+---
+apiVersion: events.k8s.io/v1
+kind: Event
+metadata: {name: bar.2, namespace: foo}
+regarding: {kind: Pod, name: bar, namespace: foo}
+reason: Unhealthy
+eventTime: "2026-10-18T10:00:10.000000Z"
+`
+	if err := os.WriteFile(filepath.Join(dir, "namespaces", "foo", "core", "events.yaml"), []byte(mixed), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gzipped, err := os.Create(filepath.Join(dir, "events.json.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(gzipped)
+	// the items of a typed list have no kind
+	if _, err := zw.Write([]byte(`{"kind":"EventList","apiVersion":"v1","items":[{"metadata":{"name":"baz.1","namespace":"foo"},"reason":"Created"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+	gzipped.Close()
+
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not an event"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// must-gathers hold files that are not objects, eg. the JSON arrays of etcd_info/ and YAML lists
+	if err := os.MkdirAll(filepath.Join(dir, "etcd_info"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "etcd_info", "endpoint_health.json"), []byte(`[{"endpoint":"https://10.0.0.1:2379","health":true}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.yaml"), []byte("- foo\n- bar\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := ReadEventFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reasons := map[string]string{}
+	for _, event := range events {
+		reasons[event.Reason] = event.Annotations[SourceAnnotation]
+	}
+	if len(events) != 3 || len(reasons) != 3 {
+		t.Fatalf("expected the BackOff, Unhealthy and Created events, got %v", reasons)
+	}
+	if reasons["Created"] != filepath.Join(dir, "events.json.gz") {
+		t.Errorf("expected the source of the gzipped event, got %q", reasons["Created"])
+	}
+}
+
+func TestReadEventFilesExplicitFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "endpoint_health.json")
+	if err := os.WriteFile(filename, []byte(`[{"endpoint":"https://10.0.0.1:2379","health":true}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// a file given explicitly is expected to hold events
+	if _, err := ReadEventFiles(filename); err == nil {
+		t.Errorf("expected an error for a file that is not an object")
+	}
+}
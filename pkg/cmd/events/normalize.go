@@ -0,0 +1,97 @@
+package events
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	eventsv1beta1 "k8s.io/api/events/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The event command works on core/v1 Events.  The events.k8s.io Events are converted to them and the timestamps and
+// count of the events emitted by the new recorder, which only sets eventTime and series, are filled in so that sorting,
+// the time window and the counts work the same for every event.
+
+// eventsV1ToCore converts an events.k8s.io/v1 Event the way the apiserver serves it as a core/v1 Event.
+func eventsV1ToCore(event *eventsv1.Event) *corev1.Event {
+	ret := &corev1.Event{
+		TypeMeta:            metav1.TypeMeta{APIVersion: "v1", Kind: "Event"},
+		ObjectMeta:          event.ObjectMeta,
+		InvolvedObject:      event.Regarding,
+		Related:             event.Related,
+		Reason:              event.Reason,
+		Message:             event.Note,
+		Type:                event.Type,
+		Action:              event.Action,
+		EventTime:           event.EventTime,
+		ReportingController: event.ReportingController,
+		ReportingInstance:   event.ReportingInstance,
+		Source:              event.DeprecatedSource,
+		FirstTimestamp:      event.DeprecatedFirstTimestamp,
+		LastTimestamp:       event.DeprecatedLastTimestamp,
+		Count:               event.DeprecatedCount,
+	}
+	if event.Series != nil {
+		ret.Series = &corev1.EventSeries{Count: event.Series.Count, LastObservedTime: event.Series.LastObservedTime}
+	}
+	return normalizeEvent(ret)
+}
+
+// eventsV1beta1ToCore converts an events.k8s.io/v1beta1 Event, it only differs from v1 by its version.
+func eventsV1beta1ToCore(event *eventsv1beta1.Event) *corev1.Event {
+	ret := &eventsv1.Event{
+		ObjectMeta:               event.ObjectMeta,
+		EventTime:                event.EventTime,
+		ReportingController:      event.ReportingController,
+		ReportingInstance:        event.ReportingInstance,
+		Action:                   event.Action,
+		Reason:                   event.Reason,
+		Regarding:                event.Regarding,
+		Related:                  event.Related,
+		Note:                     event.Note,
+		Type:                     event.Type,
+		DeprecatedSource:         event.DeprecatedSource,
+		DeprecatedFirstTimestamp: event.DeprecatedFirstTimestamp,
+		DeprecatedLastTimestamp:  event.DeprecatedLastTimestamp,
+		DeprecatedCount:          event.DeprecatedCount,
+	}
+	if event.Series != nil {
+		ret.Series = &eventsv1.EventSeries{Count: event.Series.Count, LastObservedTime: event.Series.LastObservedTime}
+	}
+	return eventsV1ToCore(ret)
+}
+
+// normalizeEvent fills the count, the first and the last timestamps of an event from its series and event time when
+// they are not set.
+func normalizeEvent(event *corev1.Event) *corev1.Event {
+	if event.Series != nil {
+		if event.Series.Count > event.Count {
+			event.Count = event.Series.Count
+		}
+		if event.LastTimestamp.IsZero() && !event.Series.LastObservedTime.IsZero() {
+			event.LastTimestamp = metav1.NewTime(event.Series.LastObservedTime.Time)
+		}
+	}
+	if event.FirstTimestamp.IsZero() {
+		switch {
+		case !event.EventTime.IsZero():
+			event.FirstTimestamp = metav1.NewTime(event.EventTime.Time)
+		case !event.LastTimestamp.IsZero():
+			event.FirstTimestamp = event.LastTimestamp
+		default:
+			event.FirstTimestamp = event.CreationTimestamp
+		}
+	}
+	if event.LastTimestamp.IsZero() {
+		event.LastTimestamp = event.FirstTimestamp
+	}
+	if event.Count == 0 {
+		event.Count = 1
+	}
+	if len(event.Source.Component) == 0 {
+		event.Source.Component = event.ReportingController
+	}
+	if len(event.Source.Host) == 0 && len(event.ReportingInstance) > 0 && event.ReportingInstance != event.ReportingController {
+		event.Source.Host = event.ReportingInstance
+	}
+	return event
+}
@@ -0,0 +1,45 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEventsV1ToCore(t *testing.T) {
+	eventTime := time.Date(2026, 10, 18, 10, 0, 10, 0, time.UTC)
+	lastObserved := eventTime.Add(3 * time.Minute)
+	event := eventsV1ToCore(&eventsv1.Event{
+		Regarding:           corev1.ObjectReference{Kind: "Pod", Namespace: "foo", Name: "bar"},
+		Reason:              "Unhealthy",
+		Note:                "Readiness probe failed",
+		Type:                corev1.EventTypeWarning,
+		ReportingController: "kubelet",
+		EventTime:           metav1.NewMicroTime(eventTime),
+		Series:              &eventsv1.EventSeries{Count: 5, LastObservedTime: metav1.NewMicroTime(lastObserved)},
+	})
+
+	if event.InvolvedObject.Name != "bar" || event.Message != "Readiness probe failed" {
+		t.Errorf("unexpected involved object %v or message %q", event.InvolvedObject, event.Message)
+	}
+	if !event.FirstTimestamp.Time.Equal(eventTime) || !event.LastTimestamp.Time.Equal(lastObserved) {
+		t.Errorf("unexpected first %v and last %v timestamps", event.FirstTimestamp, event.LastTimestamp)
+	}
+	if event.Count != 5 {
+		t.Errorf("expected the count of the series, got %d", event.Count)
+	}
+	if event.Source.Component != "kubelet" {
+		t.Errorf("expected the reporting controller as the component, got %q", event.Source.Component)
+	}
+}
+
+func TestNormalizeEventWithoutSeries(t *testing.T) {
+	eventTime := time.Date(2026, 10, 18, 10, 0, 10, 0, time.UTC)
+	event := normalizeEvent(&corev1.Event{EventTime: metav1.NewMicroTime(eventTime)})
+	if !event.LastTimestamp.Time.Equal(eventTime) || event.Count != 1 {
+		t.Errorf("unexpected last timestamp %v and count %d", event.LastTimestamp, event.Count)
+	}
+}
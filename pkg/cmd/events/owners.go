@@ -0,0 +1,234 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/dynamic"
+)
+
+// The events of a rollout are spread over an ownership chain: the deployment, its replica sets, their pods, the
+// persistent volume claims the pods mount and the nodes they ran on.  The chain is resolved with the owner references
+// of the objects of a must-gather or of a cluster.
+
+// ownerGraphResources are the namespaced resources that can be part of an ownership chain, nodes are only referenced
+// by pods.
+var ownerGraphResources = map[string]schema.GroupVersionResource{
+	"Deployment":            {Group: "apps", Version: "v1", Resource: "deployments"},
+	"StatefulSet":           {Group: "apps", Version: "v1", Resource: "statefulsets"},
+	"DaemonSet":             {Group: "apps", Version: "v1", Resource: "daemonsets"},
+	"ReplicaSet":            {Group: "apps", Version: "v1", Resource: "replicasets"},
+	"CronJob":               {Group: "batch", Version: "v1", Resource: "cronjobs"},
+	"Job":                   {Group: "batch", Version: "v1", Resource: "jobs"},
+	"Pod":                   {Version: "v1", Resource: "pods"},
+	"PersistentVolumeClaim": {Version: "v1", Resource: "persistentvolumeclaims"},
+}
+
+var ownerGraphKinds = func() sets.String {
+	kinds := sets.NewString()
+	for kind := range ownerGraphResources {
+		kinds.Insert(kind)
+	}
+	return kinds
+}()
+
+// forKinds maps the kinds, resources and short names accepted by --for to their kind.
+var forKinds = func() map[string]string {
+	ret := map[string]string{
+		"deploy": "Deployment",
+		"sts":    "StatefulSet",
+		"ds":     "DaemonSet",
+		"rs":     "ReplicaSet",
+		"cj":     "CronJob",
+		"po":     "Pod",
+		"pvc":    "PersistentVolumeClaim",
+		"node":   "Node",
+		"nodes":  "Node",
+		"no":     "Node",
+	}
+	for kind, gvr := range ownerGraphResources {
+		ret[strings.ToLower(kind)] = kind
+		ret[gvr.Resource] = kind
+	}
+	return ret
+}()
+
+// ParseForObject parses the kind/name of --for, eg. deployment/foo or sts/bar.  Nodes are cluster scoped, the namespace
+// is ignored for them.
+func ParseForObject(value, namespace string) (corev1.ObjectReference, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 || len(parts[1]) == 0 {
+		return corev1.ObjectReference{}, fmt.Errorf("%q must be kind/name, eg. deployment/foo", value)
+	}
+	kind, ok := forKinds[strings.ToLower(parts[0])]
+	if !ok {
+		return corev1.ObjectReference{}, fmt.Errorf("unsupported kind %q, available kinds are: [%s]", parts[0], strings.Join(append(ownerGraphKinds.List(), "Node"), ","))
+	}
+	if kind == "Node" {
+		return corev1.ObjectReference{Kind: kind, Name: parts[1]}, nil
+	}
+	if len(namespace) == 0 {
+		return corev1.ObjectReference{}, fmt.Errorf("%s %q requires a namespace", kind, parts[1])
+	}
+	return corev1.ObjectReference{Kind: kind, Namespace: namespace, Name: parts[1]}, nil
+}
+
+// objectKey identifies an object in an ownership chain.  Owner references have no group, the kind is enough for the
+// kinds of the chain.
+func objectKey(kind, namespace, name string) string {
+	if kind == "Node" {
+		namespace = ""
+	}
+	return kind + "/" + namespace + "/" + name
+}
+
+// OwnedObjects returns the root and every object it owns directly or transitively in objects, with the persistent
+// volume claims and the nodes of the pods.  Owners are matched by kind and name rather than UID so that the objects of
+// a deleted and recreated owner are kept, and so that the root does not have to be in objects.
+func OwnedObjects(root corev1.ObjectReference, objects []*unstructured.Unstructured) []corev1.ObjectReference {
+	byNamespace := map[string][]*unstructured.Unstructured{}
+	for _, obj := range objects {
+		byNamespace[obj.GetNamespace()] = append(byNamespace[obj.GetNamespace()], obj)
+	}
+
+	ret := []corev1.ObjectReference{}
+	seen := sets.NewString()
+	queue := []corev1.ObjectReference{root}
+	add := func(ref corev1.ObjectReference) {
+		if key := objectKey(ref.Kind, ref.Namespace, ref.Name); !seen.Has(key) {
+			seen.Insert(key)
+			queue = append(queue, ref)
+		}
+	}
+	seen.Insert(objectKey(root.Kind, root.Namespace, root.Name))
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		ret = append(ret, current)
+		if current.Kind == "Node" {
+			continue
+		}
+
+		for _, obj := range byNamespace[current.Namespace] {
+			if obj.GetKind() == current.Kind && obj.GetName() == current.Name {
+				for _, ref := range podReferences(obj) {
+					add(ref)
+				}
+				continue
+			}
+			for _, owner := range obj.GetOwnerReferences() {
+				if owner.Kind == current.Kind && owner.Name == current.Name {
+					add(corev1.ObjectReference{Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()})
+					break
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// podReferences returns the node and the persistent volume claims of a pod.
+func podReferences(obj *unstructured.Unstructured) []corev1.ObjectReference {
+	if obj.GetKind() != "Pod" {
+		return nil
+	}
+	ret := []corev1.ObjectReference{}
+	if nodeName, _, _ := unstructured.NestedString(obj.Object, "spec", "nodeName"); len(nodeName) > 0 {
+		ret = append(ret, corev1.ObjectReference{Kind: "Node", Name: nodeName})
+	}
+	volumes, _, _ := unstructured.NestedSlice(obj.Object, "spec", "volumes")
+	for _, volume := range volumes {
+		volumeObj, ok := volume.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if claimName, _, _ := unstructured.NestedString(volumeObj, "persistentVolumeClaim", "claimName"); len(claimName) > 0 {
+			ret = append(ret, corev1.ObjectReference{Kind: "PersistentVolumeClaim", Namespace: obj.GetNamespace(), Name: claimName})
+		}
+	}
+	return ret
+}
+
+// ReadClusterEventsAndResources lists the events and the objects that can be part of an ownership chain in a namespace
+// of the cluster of the config flags.  The node events are not namespaced, they are listed with ReadClusterNodeEvents
+// once the nodes of the chain are known.
+func ReadClusterEventsAndResources(configFlags *genericclioptions.ConfigFlags, namespace string) ([]*corev1.Event, []*unstructured.Unstructured, error) {
+	client, err := newDynamicClient(configFlags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resources := []*unstructured.Unstructured{}
+	for _, kind := range ownerGraphKinds.List() {
+		list, err := client.Resource(ownerGraphResources[kind]).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to list %s in %q: %w", ownerGraphResources[kind].Resource, namespace, err)
+		}
+		for i := range list.Items {
+			list.Items[i].SetGroupVersionKind(ownerGraphResources[kind].GroupVersion().WithKind(kind))
+			resources = append(resources, &list.Items[i])
+		}
+	}
+
+	events, err := listClusterEvents(client, namespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return events, resources, nil
+}
+
+// ReadClusterNodeEvents lists the events of the nodes of the cluster of the config flags.
+func ReadClusterNodeEvents(configFlags *genericclioptions.ConfigFlags, nodes []string) ([]*corev1.Event, error) {
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+	client, err := newDynamicClient(configFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*corev1.Event{}
+	for _, node := range nodes {
+		events, err := listClusterEvents(client, metav1.NamespaceAll, metav1.ListOptions{
+			FieldSelector: "involvedObject.kind=Node,involvedObject.name=" + node,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, events...)
+	}
+	return ret, nil
+}
+
+func newDynamicClient(configFlags *genericclioptions.ConfigFlags) (dynamic.Interface, error) {
+	restConfig, err := configFlags.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(restConfig)
+}
+
+func listClusterEvents(client dynamic.Interface, namespace string, options metav1.ListOptions) ([]*corev1.Event, error) {
+	list, err := client.Resource(corev1.SchemeGroupVersion.WithResource("events")).Namespace(namespace).List(context.TODO(), options)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list events: %w", err)
+	}
+	ret := []*corev1.Event{}
+	for i := range list.Items {
+		list.Items[i].SetGroupVersionKind(coreEventGVK)
+		event, err := toEvent(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, event)
+	}
+	return ret, nil
+}
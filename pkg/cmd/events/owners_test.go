@@ -0,0 +1,55 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestOwnedObjects(t *testing.T) {
+	objects := []*unstructured.Unstructured{
+		{Object: map[string]interface{}{"kind": "Deployment", "metadata": map[string]interface{}{"name": "foo", "namespace": "bar"}}},
+		{Object: map[string]interface{}{"kind": "ReplicaSet", "metadata": map[string]interface{}{"name": "foo-1", "namespace": "bar",
+			"ownerReferences": []interface{}{map[string]interface{}{"kind": "Deployment", "name": "foo"}}}}},
+		{Object: map[string]interface{}{"kind": "Pod", "metadata": map[string]interface{}{"name": "foo-1-a", "namespace": "bar",
+			"ownerReferences": []interface{}{map[string]interface{}{"kind": "ReplicaSet", "name": "foo-1"}}},
+			"spec": map[string]interface{}{"nodeName": "worker-1", "volumes": []interface{}{
+				map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "foo-data"}},
+			}}}},
+		// another deployment and a pod of the same name in another namespace are not part of the chain
+		{Object: map[string]interface{}{"kind": "ReplicaSet", "metadata": map[string]interface{}{"name": "other-1", "namespace": "bar",
+			"ownerReferences": []interface{}{map[string]interface{}{"kind": "Deployment", "name": "other"}}}}},
+		{Object: map[string]interface{}{"kind": "Pod", "metadata": map[string]interface{}{"name": "foo-1-b", "namespace": "baz",
+			"ownerReferences": []interface{}{map[string]interface{}{"kind": "ReplicaSet", "name": "foo-1"}}}}},
+	}
+
+	actual := OwnedObjects(corev1.ObjectReference{Kind: "Deployment", Namespace: "bar", Name: "foo"}, objects)
+	expected := []corev1.ObjectReference{
+		{Kind: "Deployment", Namespace: "bar", Name: "foo"},
+		{Kind: "ReplicaSet", Namespace: "bar", Name: "foo-1"},
+		{Kind: "Pod", Namespace: "bar", Name: "foo-1-a"},
+		{Kind: "Node", Name: "worker-1"},
+		{Kind: "PersistentVolumeClaim", Namespace: "bar", Name: "foo-data"},
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestFilterByInvolvedObjects(t *testing.T) {
+	filter := &FilterByInvolvedObjects{Objects: []corev1.ObjectReference{
+		{Kind: "Pod", Namespace: "bar", Name: "foo-1-a"},
+		{Kind: "Node", Name: "worker-1"},
+	}}
+	events := filter.FilterEvents(
+		&corev1.Event{InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "bar", Name: "foo-1-a"}},
+		&corev1.Event{InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "baz", Name: "foo-1-a"}},
+		// node events are recorded in the default namespace
+		&corev1.Event{InvolvedObject: corev1.ObjectReference{Kind: "Node", Namespace: "default", Name: "worker-1"}},
+	)
+	if len(events) != 2 || events[1].InvolvedObject.Kind != "Node" {
+		t.Errorf("unexpected events %v", events)
+	}
+}
@@ -32,6 +32,8 @@ var (
 		"seLinuxOptions":                    empty,
 		"unrestricted capabilities":         empty,
 	}
+
+	auditViolationsRegex = regexp.MustCompile(`would violate PodSecurity "([^"]+)": (.*)`)
 )
 
 // parseWarnings parses the warnings that are returned by the API request and
@@ -99,3 +101,43 @@ func parseWarnings(warnings []string) *PodSecurityViolation {
 
 	return &psv
 }
+
+// ParseAuditViolations parses the pod-security.kubernetes.io/audit-violations annotation that the pod security
+// admission adds to audit events. It returns the level and the known violations, without their details.
+//
+// Example Annotation:
+// would violate PodSecurity "restricted:latest": allowPrivilegeEscalation != false (container "foo" must set securityContext.allowPrivilegeEscalation=false), unrestricted capabilities (container "foo" must set securityContext.capabilities.drop=["ALL"])
+func ParseAuditViolations(annotation string) (string, []string) {
+	matches := auditViolationsRegex.FindStringSubmatch(annotation)
+	if len(matches) != 3 {
+		return "", nil
+	}
+
+	// The details in parentheses contain ", " too, so only split outside of them.
+	candidates := []string{}
+	depth, start := 0, 0
+	text := matches[2]
+	for i, c := range text {
+		switch {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(text[i:], ", "):
+			candidates = append(candidates, text[start:i])
+			start = i + len(", ")
+		}
+	}
+	candidates = append(candidates, text[start:])
+
+	matchedCandidates := []string{}
+	for _, candidate := range candidates {
+		candidate = strings.TrimSpace(strings.Split(candidate, " (")[firstMatch])
+		// It could be that there are new violations in the pod-security-admission code.
+		if _, ok := violations[candidate]; ok {
+			matchedCandidates = append(matchedCandidates, candidate)
+		}
+	}
+
+	return matches[1], matchedCandidates
+}
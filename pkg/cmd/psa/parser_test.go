@@ -69,3 +69,47 @@ func TestParseWarnings(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAuditViolations(t *testing.T) {
+	tests := []struct {
+		name               string
+		annotation         string
+		expectedLevel      string
+		expectedViolations []string
+	}{
+		{
+			name:       "unrelated annotation",
+			annotation: `allowed by ClusterRoleBinding "foo"`,
+		},
+		{
+			name:               "violations with details",
+			annotation:         `would violate PodSecurity "restricted:latest": allowPrivilegeEscalation != false (container "foo" must set securityContext.allowPrivilegeEscalation=false), unrestricted capabilities (containers "foo", "bar" must set securityContext.capabilities.drop=["ALL"]), runAsNonRoot != true (pod or container "foo" must set securityContext.runAsNonRoot=true)`,
+			expectedLevel:      "restricted:latest",
+			expectedViolations: []string{"allowPrivilegeEscalation != false", "unrestricted capabilities", "runAsNonRoot != true"},
+		},
+		{
+			name:               "unknown violations are skipped",
+			annotation:         `would violate PodSecurity "baseline:v1.24": hostPath volumes (volume "etc"), some future check (details)`,
+			expectedLevel:      "baseline:v1.24",
+			expectedViolations: []string{"hostPath volumes"},
+		},
+		{
+			name:               "only unknown violations",
+			annotation:         `would violate PodSecurity "baseline:v1.24": some future check (details)`,
+			expectedLevel:      "baseline:v1.24",
+			expectedViolations: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			level, violations := ParseAuditViolations(tc.annotation)
+			if level != tc.expectedLevel {
+				t.Errorf("expected level: %q, actual: %q", tc.expectedLevel, level)
+			}
+			if !reflect.DeepEqual(violations, tc.expectedViolations) {
+				t.Errorf("expected: %v, actual: %v", tc.expectedViolations, violations)
+			}
+		})
+	}
+}
@@ -39,7 +39,7 @@ func (o *PSAOptions) Run() error {
 		}
 
 		klog.V(4).Infof(
-			"Pod %q has pod security violations, gathering Pod and Deployment Resources",
+			"Pod %q in namespace %q has pod security violations, gathering Pod and Deployment Resources",
 			psv.PodName,
 			namespace.Name,
 		)
@@ -235,7 +235,7 @@ func (o *PSAOptions) getPodController(pod *corev1.Pod, parent metav1.OwnerRefere
 
 	klog.Warningf(
 		"%s isn't owned by a known pod controller: pod.Name=%s, pod.Namespace=%s, pod.OwnerReferences=%v",
-		parent.Kind, pod.Name, pod.OwnerReferences, pod.ObjectMeta.OwnerReferences,
+		parent.Kind, pod.Name, pod.Namespace, pod.OwnerReferences,
 	)
 
 	return nil, nil
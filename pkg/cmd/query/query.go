@@ -0,0 +1,116 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/openshift/cluster-debug-tools/pkg/util"
+)
+
+var (
+	queryListExample = `
+	# list the saved queries of ~/.config/kubectl-dev_tool/queries.yaml and of .kubectl-dev_tool/queries.yaml of the project
+	%[1]s query list
+
+	# list the saved queries of the audit command only
+	%[1]s query list --command=audit
+`
+)
+
+func NewCmdQuery(parentName string, streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "query",
+		Short: "Manages the saved queries of the audit and event commands.",
+		Long: `Manages the saved queries of the audit and event commands.
+
+Queries are read from ~/.config/kubectl-dev_tool/queries.yaml and from .kubectl-dev_tool/queries.yaml in the current
+directory or its closest parent that has one, a query of the project overrides the query of the user with the same
+name.  A query file looks like:
+
+  queries:
+  - name: gc-deployments
+    description: GC calls to deployments
+    command: audit
+    filters:
+      user: system:serviceaccount:kube-system:generic-garbage-collector
+      resource: [deployments.*]
+    output: top
+    by: verb
+
+The filters are the flags of the command, run a query with eg. audit --query=gc-deployments.`,
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(NewCmdQueryList(parentName, streams))
+
+	return cmd
+}
+
+type QueryListOptions struct {
+	command string
+
+	genericclioptions.IOStreams
+}
+
+func NewQueryListOptions(streams genericclioptions.IOStreams) *QueryListOptions {
+	return &QueryListOptions{
+		IOStreams: streams,
+	}
+}
+
+func NewCmdQueryList(parentName string, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewQueryListOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:          "list",
+		Short:        "Lists the saved queries.",
+		Example:      fmt.Sprintf(queryListExample, parentName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			if err := o.Run(); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&o.command, "command", o.command, "Only list the queries of a command (eg. audit, event).")
+
+	return cmd
+}
+
+func (o *QueryListOptions) Validate() error {
+	switch o.command {
+	case "", "audit", "event":
+		return nil
+	default:
+		return fmt.Errorf("unsupported command %q, available values are: [audit,event]", o.command)
+	}
+}
+
+func (o *QueryListOptions) Run() error {
+	queries, err := util.LoadQueries()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintf(w, "NAME\tCOMMAND\tDESCRIPTION\tFLAGS\tSOURCE\n")
+	for _, query := range queries {
+		if len(o.command) > 0 && query.Command != o.command {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", query.Name, query.Command, query.Description, strings.Join(query.Args(), " "), query.Source)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package timeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"text/tabwriter"
+)
+
+const entryTimeFormat = "2006-01-02 15:04:05.000"
+
+func PrintTimeline(writer io.Writer, entries []*Entry) {
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "TIME\tSOURCE\tLEVEL\tNAMESPACE\tOBJECT\tMESSAGE\n")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.Time.UTC().Format(entryTimeFormat),
+			entry.Source,
+			entry.Level,
+			entry.Namespace,
+			entry.Object,
+			entry.Message)
+	}
+}
+
+func PrintTimelineJSON(writer io.Writer, entries []*Entry) error {
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var timelineHTMLTemplate = template.Must(template.New("timeline").Funcs(template.FuncMap{
+	"formatTime": func(entry *Entry) string { return entry.Time.UTC().Format(entryTimeFormat) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Timeline</title>
+<style>
+body { font-family: sans-serif; font-size: 13px; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; vertical-align: top; padding: 2px 6px; border-bottom: 1px solid #eee; }
+td.message { font-family: monospace; white-space: pre-wrap; word-break: break-all; }
+tr.audit { background: #f4f8ff; }
+tr.event { background: #f6fff4; }
+tr.clusteroperator { background: #fff9ec; }
+tr.podlog { background: #ffffff; }
+tr.Warning td.level, tr.failed td.level, tr.warning td.level { color: #b36b00; font-weight: bold; }
+tr.error td.level, tr.fatal td.level { color: #c00; font-weight: bold; }
+</style>
+</head>
+<body>
+<p>
+{{- range .Sources }}
+<label><input type="checkbox" checked onchange="toggle('{{ . }}', this.checked)"> {{ . }}</label>
+{{- end }}
+</p>
+<table>
+<tr><th>Time</th><th>Source</th><th>Level</th><th>Namespace</th><th>Object</th><th>Message</th></tr>
+{{- range .Entries }}
+<tr class="{{ .Source }} {{ .Level }}"><td>{{ formatTime . }}</td><td>{{ .Source }}</td><td class="level">{{ .Level }}</td><td>{{ .Namespace }}</td><td>{{ .Object }}</td><td class="message">{{ .Message }}</td></tr>
+{{- end }}
+</table>
+<script>
+function toggle(source, visible) {
+  document.querySelectorAll("tr." + source).forEach(function (row) { row.style.display = visible ? "" : "none"; });
+}
+</script>
+</body>
+</html>
+`))
+
+// PrintTimelineHTML writes a standalone page, the sources can be hidden with a checkbox.
+func PrintTimelineHTML(writer io.Writer, entries []*Entry) error {
+	return timelineHTMLTemplate.Execute(writer, struct {
+		Sources []string
+		Entries []*Entry
+	}{
+		Sources: Sources,
+		Entries: entries,
+	})
+}
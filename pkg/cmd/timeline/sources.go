@@ -0,0 +1,382 @@
+package timeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	"k8s.io/klog"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+	"github.com/openshift/cluster-debug-tools/pkg/cmd/events"
+)
+
+// the sources of a timeline
+const (
+	SourceAudit           = "audit"
+	SourceEvent           = "event"
+	SourceClusterOperator = "clusteroperator"
+	SourcePodLog          = "podlog"
+)
+
+var Sources = []string{SourceAudit, SourceEvent, SourceClusterOperator, SourcePodLog}
+
+// Entry is a single line of a timeline.
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Source    string    `json:"source"`
+	Namespace string    `json:"namespace,omitempty"`
+	// Object is the object the entry is about, eg. pods/foo or clusteroperators/etcd.
+	Object string `json:"object,omitempty"`
+	// Level is the severity of the entry when the source has one: Normal or Warning for events, info, warning, error
+	// or fatal for klog lines and failed for failed requests.
+	Level   string `json:"level,omitempty"`
+	Message string `json:"message"`
+}
+
+// artifacts are the files of the sources found in a must-gather or a CI artifacts directory.
+type artifacts struct {
+	auditLogs        []string
+	eventFiles       []string
+	clusterOperators []string
+	podLogs          []string
+}
+
+// findArtifacts walks a must-gather or a CI artifacts directory:
+//   - audit logs are the .log and .log.gz files with audit in their path, eg. audit_logs/kube-apiserver/*.log.gz
+//   - events are the events.yaml and events.json files, plain or gzipped, eg. namespaces/*/core/events.yaml
+//   - cluster operators are the files with clusteroperators in their path, eg.
+//     cluster-scoped-resources/config.openshift.io/clusteroperators/*.yaml or clusteroperators.json
+//   - pod logs are the other .log files with pods in their path, eg. namespaces/*/pods/*/*/*/logs/current.log or
+//     pods/<namespace>_<pod>_<container>.log
+func findArtifacts(root string) (*artifacts, error) {
+	ret := &artifacts{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relative, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		relative = filepath.ToSlash(relative)
+		name := info.Name()
+		isLog := strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".log.gz")
+
+		switch {
+		case isLog && strings.Contains(relative, "audit"):
+			ret.auditLogs = append(ret.auditLogs, path)
+		case strings.HasPrefix(name, "events.") && (strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".gz")):
+			ret.eventFiles = append(ret.eventFiles, path)
+		case strings.Contains(relative, "clusteroperators") && (strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".json")):
+			ret.clusterOperators = append(ret.clusterOperators, path)
+		case strings.HasSuffix(name, ".log") && strings.Contains(relative, "pods/"):
+			ret.podLogs = append(ret.podLogs, path)
+		}
+		return nil
+	})
+	return ret, err
+}
+
+// auditEntries reads the completed requests of the audit logs.
+func auditEntries(files []string, filters audit.Filters) ([]*Entry, error) {
+	ret := []*Entry{}
+	source := audit.NewFileSource(files...)
+	err := source.Visit(func(event *auditv1.Event) error {
+		if !filters.Matches(event) {
+			return nil
+		}
+		ret = append(ret, auditEntry(event))
+		return nil
+	})
+	if readFailures := source.ReadFailures(); readFailures > 0 {
+		klog.Warningf("had %d audit line read failures", readFailures)
+	}
+	return ret, err
+}
+
+func auditEntry(event *auditv1.Event) *Entry {
+	entry := &Entry{
+		Time:    event.RequestReceivedTimestamp.Time,
+		Source:  SourceAudit,
+		Message: fmt.Sprintf("%s [%d] %s [%s]", strings.ToUpper(event.Verb), audit.ResponseCode(event), event.RequestURI, event.User.Username),
+	}
+	if code := audit.ResponseCode(event); code > 299 {
+		entry.Level = "failed"
+	}
+	if event.ObjectRef != nil {
+		entry.Namespace = event.ObjectRef.Namespace
+		entry.Object = event.ObjectRef.Resource
+		if len(event.ObjectRef.Name) > 0 {
+			entry.Object += "/" + event.ObjectRef.Name
+		}
+	}
+	return entry
+}
+
+// eventEntries reads the events, an event seen several times is on the timeline when it was first and last seen.  The
+// timestamps of the events of the new recorder are filled in by the event loader.
+func eventEntries(files []string) ([]*Entry, error) {
+	coreEvents, err := events.ReadEventFiles(files...)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*Entry{}
+	for _, event := range coreEvents {
+		namespace := event.InvolvedObject.Namespace
+		if len(namespace) == 0 {
+			namespace = event.Namespace
+		}
+		object := strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name
+		message := fmt.Sprintf("%s: %s", event.Reason, strings.ReplaceAll(event.Message, "\n", " "))
+		last := event.LastTimestamp.Time
+		if event.Count > 1 {
+			message = fmt.Sprintf("(%dx) %s", event.Count, message)
+			if first := event.FirstTimestamp.Time; !first.IsZero() && first.Before(last) {
+				ret = append(ret, &Entry{Time: first, Source: SourceEvent, Namespace: namespace, Object: object, Level: event.Type, Message: "first seen: " + message})
+			}
+		}
+		ret = append(ret, &Entry{Time: last, Source: SourceEvent, Namespace: namespace, Object: object, Level: event.Type, Message: message})
+	}
+	return ret, nil
+}
+
+// clusterOperator is the part of a ClusterOperator or of a list of them the timeline needs.
+type clusterOperator struct {
+	Kind     string            `json:"kind"`
+	Metadata metav1.ObjectMeta `json:"metadata"`
+	Status   struct {
+		Conditions []struct {
+			Type               string      `json:"type"`
+			Status             string      `json:"status"`
+			Reason             string      `json:"reason"`
+			Message            string      `json:"message"`
+			LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+		} `json:"conditions"`
+	} `json:"status"`
+	Items []clusterOperator `json:"items"`
+}
+
+// clusterOperatorEntries reads the last transition of every condition of the cluster operators, a snapshot doesn't
+// have the earlier ones.
+func clusterOperatorEntries(files []string) ([]*Entry, error) {
+	ret := []*Entry{}
+	for _, file := range files {
+		operators, err := readClusterOperators(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, operator := range operators {
+			for _, condition := range operator.Status.Conditions {
+				if condition.LastTransitionTime.IsZero() {
+					continue
+				}
+				message := fmt.Sprintf("%s=%s %s", condition.Type, condition.Status, condition.Reason)
+				if len(condition.Message) > 0 {
+					message += ": " + strings.ReplaceAll(condition.Message, "\n", " ")
+				}
+				entry := &Entry{
+					Time:    condition.LastTransitionTime.Time,
+					Source:  SourceClusterOperator,
+					Object:  "clusteroperators/" + operator.Metadata.Name,
+					Message: message,
+				}
+				if isBadCondition(condition.Type, condition.Status) {
+					entry.Level = "Warning"
+				}
+				ret = append(ret, entry)
+			}
+		}
+	}
+	return ret, nil
+}
+
+// isBadCondition is true for the conditions of an operator that needs attention.
+func isBadCondition(conditionType, status string) bool {
+	switch conditionType {
+	case "Available", "Upgradeable":
+		return status != "True"
+	case "Degraded":
+		return status != "False"
+	}
+	return false
+}
+
+func readClusterOperators(file string) ([]clusterOperator, error) {
+	reader, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	ret := []clusterOperator{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(reader, 4096)
+	for {
+		operator := clusterOperator{}
+		if err := decoder.Decode(&operator); err != nil {
+			if err == io.EOF {
+				return ret, nil
+			}
+			return nil, fmt.Errorf("unable to read %q: %v", file, err)
+		}
+		switch operator.Kind {
+		case "ClusterOperator":
+			ret = append(ret, operator)
+		case "ClusterOperatorList", "List":
+			for _, item := range operator.Items {
+				if item.Kind == "ClusterOperator" || len(item.Kind) == 0 {
+					ret = append(ret, item)
+				}
+			}
+		}
+	}
+}
+
+var (
+	// klogHeaderRegex matches the header of klog lines, eg. I1018 10:00:00.123456
+	klogHeaderRegex = regexp.MustCompile(`^([IWEF])([0-9]{2})([0-9]{2}) ([0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]{6})`)
+	klogLevels      = map[string]string{"I": "info", "W": "warning", "E": "error", "F": "fatal"}
+)
+
+// podLogObject returns the namespace and the pod/container of a pod log from its path, either
+// namespaces/<namespace>/pods/<pod>/<container>/<container>/logs/<file>.log of a must-gather or
+// pods/<namespace>_<pod>_<container>.log of a CI run.
+func podLogObject(path string) (string, string) {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] != "pods" {
+			continue
+		}
+		switch {
+		case i >= 2 && parts[i-2] == "namespaces" && i+2 < len(parts):
+			return parts[i-1], "pods/" + parts[i+1] + "/" + parts[i+2]
+		case i+1 < len(parts):
+			name := strings.TrimSuffix(strings.TrimSuffix(parts[i+1], ".log"), "_previous")
+			if nameParts := strings.SplitN(name, "_", 3); len(nameParts) == 3 {
+				return nameParts[0], "pods/" + nameParts[1] + "/" + nameParts[2]
+			}
+			return "", "pods/" + name
+		}
+	}
+	return "", filepath.Base(path)
+}
+
+// podLogEntries reads the pod log lines that have a timestamp: an RFC3339 timestamp like the lines of oc logs
+// --timestamps and of a must-gather, or a klog header.  A klog header has no year, it is the year the file was last
+// written in.
+func podLogEntries(files []string) ([]*Entry, error) {
+	ret := []*Entry{}
+	for _, file := range files {
+		entries, err := readPodLog(file)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, entries...)
+	}
+	return ret, nil
+}
+
+// maxPodLogLineSize is where pod log lines are truncated, the timestamp and the klog header are at their start.
+const maxPodLogLineSize = 64 * 1024
+
+func readPodLog(file string) ([]*Entry, error) {
+	reader, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	info, err := reader.Stat()
+	if err != nil {
+		return nil, err
+	}
+	modTime := info.ModTime().UTC()
+
+	namespace, object := podLogObject(file)
+	ret := []*Entry{}
+	err = readLines(reader, maxPodLogLineSize, func(line string) {
+		entry := &Entry{Source: SourcePodLog, Namespace: namespace, Object: object}
+
+		if end := strings.Index(line, " "); end > 0 {
+			if t, err := time.Parse(time.RFC3339Nano, line[:end]); err == nil {
+				entry.Time = t
+				line = line[end+1:]
+			}
+		}
+		if matches := klogHeaderRegex.FindStringSubmatch(line); matches != nil {
+			entry.Level = klogLevels[matches[1]]
+			if entry.Time.IsZero() {
+				entry.Time = klogTime(matches, modTime)
+			}
+		}
+		if entry.Time.IsZero() {
+			return
+		}
+		entry.Message = line
+		ret = append(ret, entry)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %q: %v", file, err)
+	}
+	return ret, nil
+}
+
+// readLines hands every line to handle, the lines longer than maxLineSize are truncated.
+func readLines(reader io.Reader, maxLineSize int, handle func(line string)) error {
+	bufferedReader := bufio.NewReaderSize(reader, 64*1024)
+	line := []byte{}
+	truncated := false
+	for {
+		chunk, err := bufferedReader.ReadSlice('\n')
+		if len(line) < maxLineSize {
+			line = append(line, chunk...)
+		} else {
+			truncated = true
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if len(line) > 0 {
+			text := strings.TrimRight(string(line), "\r\n")
+			if truncated || len(text) > maxLineSize {
+				if len(text) > maxLineSize {
+					text = text[:maxLineSize]
+				}
+				text += " (truncated)"
+			}
+			handle(text)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		line = line[:0]
+		truncated = false
+	}
+}
+
+// klogTime resolves the time of a klog header, the header is assumed to be at most a year older than the file.
+func klogTime(matches []string, modTime time.Time) time.Time {
+	t, err := time.Parse("2006 0102 15:04:05.000000", fmt.Sprintf("%d %s%s %s", modTime.Year(), matches[2], matches[3], matches[4]))
+	if err != nil {
+		return time.Time{}
+	}
+	if t.After(modTime.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
@@ -0,0 +1,138 @@
+package timeline
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPodLogObject(t *testing.T) {
+	tests := []struct {
+		path              string
+		expectedNamespace string
+		expectedObject    string
+	}{
+		{
+			path:              "must-gather/namespaces/openshift-etcd/pods/etcd-0/etcd/etcd/logs/current.log",
+			expectedNamespace: "openshift-etcd",
+			expectedObject:    "pods/etcd-0/etcd",
+		},
+		{
+			path:              "artifacts/gather-extra/artifacts/pods/openshift-etcd_etcd-0_etcd_previous.log",
+			expectedNamespace: "openshift-etcd",
+			expectedObject:    "pods/etcd-0/etcd",
+		},
+		{
+			path:              "artifacts/pods/unknown.log",
+			expectedNamespace: "",
+			expectedObject:    "pods/unknown",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			namespace, object := podLogObject(tc.path)
+			if namespace != tc.expectedNamespace || object != tc.expectedObject {
+				t.Errorf("expected %q %q, got %q %q", tc.expectedNamespace, tc.expectedObject, namespace, object)
+			}
+		})
+	}
+}
+
+func TestKlogTime(t *testing.T) {
+	modTime := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	matches := klogHeaderRegex.FindStringSubmatch("E1231 23:59:58.500000 1 main.go:1] failed")
+	if matches == nil {
+		t.Fatal("expected a klog header")
+	}
+	expected := time.Date(2025, 12, 31, 23, 59, 58, 500000000, time.UTC)
+	if actual := klogTime(matches, modTime); !actual.Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestFindArtifacts(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		"audit_logs/kube-apiserver/master-0-audit.log.gz",
+		"audit_logs/openshift-apiserver/master-0-audit-2026-10-18T10-00-00.000.log",
+		"namespaces/openshift-etcd/core/events.yaml",
+		"namespaces/openshift-etcd/core/pods.yaml",
+		"gather-extra/events.json.gz",
+		"cluster-scoped-resources/config.openshift.io/clusteroperators/etcd.yaml",
+		"namespaces/openshift-etcd/pods/etcd-0/etcd/etcd/logs/current.log",
+		"pods/openshift-etcd_etcd-0_etcd.log",
+		"host_service_logs/masters/kubelet_service.log",
+	}
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found, err := findArtifacts(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	relative := func(paths []string) []string {
+		ret := []string{}
+		for _, path := range paths {
+			rel, _ := filepath.Rel(root, path)
+			ret = append(ret, filepath.ToSlash(rel))
+		}
+		return ret
+	}
+	for name, actual := range map[string][]string{
+		"audit logs":        relative(found.auditLogs),
+		"event files":       relative(found.eventFiles),
+		"cluster operators": relative(found.clusterOperators),
+		"pod logs":          relative(found.podLogs),
+	} {
+		expected := map[string][]string{
+			"audit logs":        {files[0], files[1]},
+			"event files":       {files[4], files[2]},
+			"cluster operators": {files[5]},
+			"pod logs":          {files[6], files[7]},
+		}[name]
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected %s %v, got %v", name, expected, actual)
+		}
+	}
+}
+
+func TestReadPodLogLongLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pods", "foo_bar_baz.log")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "2026-10-18T10:00:00Z first\n" +
+		"2026-10-18T10:00:01Z " + strings.Repeat("x", 3*maxPodLogLineSize) + "\n" +
+		"2026-10-18T10:00:02Z last"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readPodLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Message != "first" || entries[2].Message != "last" {
+		t.Errorf("unexpected messages %q and %q", entries[0].Message, entries[2].Message)
+	}
+	if len(entries[1].Message) > maxPodLogLineSize+len(" (truncated)") || !strings.HasSuffix(entries[1].Message, "(truncated)") {
+		t.Errorf("expected the long line to be truncated, got %d bytes", len(entries[1].Message))
+	}
+	if entries[1].Namespace != "foo" || entries[1].Object != "pods/bar/baz" {
+		t.Errorf("unexpected object %q %q", entries[1].Namespace, entries[1].Object)
+	}
+}
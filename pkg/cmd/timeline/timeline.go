@@ -0,0 +1,212 @@
+package timeline
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/openshift/cluster-debug-tools/pkg/audit"
+	"github.com/openshift/cluster-debug-tools/pkg/util"
+)
+
+var (
+	timelineExample = `
+	# merge the audit logs, events, cluster operator conditions and pod logs of a must-gather into a single timeline
+	%[1]s timeline -f must-gather/
+
+	# show what happened in the etcd namespace five minutes around 10:12 UTC of a CI run
+	%[1]s timeline -f artifacts/ --namespace=openshift-etcd --around=10:12 --window=5m
+
+	# write the events and the cluster operator conditions of the last half hour as HTML
+	%[1]s timeline -f must-gather/ --source=event,clusteroperator --after=end-30m -o html > timeline.html
+`
+)
+
+type TimelineOptions struct {
+	directories []string
+	sources     []string
+	namespaces  []string
+	timeWindow  *util.TimeWindowOptions
+	output      string
+
+	genericclioptions.IOStreams
+}
+
+func NewTimelineOptions(streams genericclioptions.IOStreams) *TimelineOptions {
+	return &TimelineOptions{
+		sources:    Sources,
+		timeWindow: util.NewTimeWindowOptions(),
+		IOStreams:  streams,
+	}
+}
+
+func NewCmdTimeline(parentName string, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewTimelineOptions(streams)
+
+	cmd := &cobra.Command{
+		Use:          "timeline -f=must-gather [flags]",
+		Short:        "Merges the audit logs, events, cluster operator conditions and pod logs of a must-gather or CI run into one timeline.",
+		Example:      fmt.Sprintf(timelineExample, parentName),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			if err := o.Run(); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&o.directories, "filename", "f", o.directories, "A must-gather or CI artifacts directory.")
+	cmd.Flags().StringSliceVar(&o.sources, "source", o.sources, "The sources merged into the timeline: "+strings.Join(Sources, ", ")+".")
+	cmd.Flags().StringSliceVarP(&o.namespaces, "namespace", "n", o.namespaces, "Filter result of search to only contain the specified namespace.")
+	cmd.Flags().StringVarP(&o.output, "output", "o", o.output, "Choose your output format: text, json, html.")
+	o.timeWindow.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func (o *TimelineOptions) Validate() error {
+	if len(o.directories) == 0 {
+		return fmt.Errorf("-f is required")
+	}
+	for _, directory := range o.directories {
+		if info, err := os.Stat(directory); err != nil {
+			return err
+		} else if !info.IsDir() {
+			return fmt.Errorf("%q is not a directory", directory)
+		}
+	}
+	for _, source := range o.sources {
+		if !sets.NewString(Sources...).Has(source) {
+			return fmt.Errorf("unsupported --source value %q, available values are: [%s]", source, strings.Join(Sources, ","))
+		}
+	}
+	switch o.output {
+	case "", "text", "json", "html":
+	default:
+		return fmt.Errorf("unsupported output format: text, json, html")
+	}
+	return o.timeWindow.Validate()
+}
+
+func (o *TimelineOptions) Run() error {
+	// an absolute time window is applied while reading, so that only the requests within it are kept, the audit logs
+	// are visited a few files at a time
+	var after, before time.Time
+	var err error
+	if !o.timeWindow.NeedsTimeSpan() {
+		if after, before, err = o.timeWindow.ToTimeWindow(util.TimeSpan{}); err != nil {
+			return err
+		}
+	}
+
+	entries := []*Entry{}
+	for _, directory := range o.directories {
+		directoryEntries, err := o.readDirectory(directory, after, before)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, directoryEntries...)
+	}
+
+	if o.timeWindow.NeedsTimeSpan() {
+		span := util.TimeSpan{}
+		for _, entry := range entries {
+			span.Include(entry.Time)
+		}
+		if after, before, err = o.timeWindow.ToTimeWindow(span); err != nil {
+			return err
+		}
+	}
+	filtered := filterEntries(entries, after, before, sets.NewString(o.namespaces...))
+
+	switch o.output {
+	case "", "text":
+		PrintTimeline(o.Out, filtered)
+	case "json":
+		return PrintTimelineJSON(o.Out, filtered)
+	case "html":
+		return PrintTimelineHTML(o.Out, filtered)
+	}
+	return nil
+}
+
+// filterEntries keeps the entries within the time window, a zero time leaves that side open, and in the namespaces.  The
+// entries of all the sources are merged by time, the entries of the same time keep the order of their source.
+func filterEntries(entries []*Entry, after, before time.Time, namespaces sets.String) []*Entry {
+	ret := []*Entry{}
+	for _, entry := range entries {
+		if !after.IsZero() && !entry.Time.After(after) {
+			continue
+		}
+		if !before.IsZero() && !entry.Time.Before(before) {
+			continue
+		}
+		if len(namespaces) > 0 && !util.AcceptString(namespaces, entry.Namespace) {
+			continue
+		}
+		ret = append(ret, entry)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Time.Before(ret[j].Time)
+	})
+	return ret
+}
+
+// readDirectory reads the entries of the selected sources of a must-gather or CI artifacts directory.  The absolute
+// time window, if any, is applied to the audit logs while they are read.
+func (o *TimelineOptions) readDirectory(directory string, after, before time.Time) ([]*Entry, error) {
+	found, err := findArtifacts(directory)
+	if err != nil {
+		return nil, err
+	}
+	sources := sets.NewString(o.sources...)
+
+	ret := []*Entry{}
+	if sources.Has(SourceAudit) {
+		// the namespace filter is applied early, audit logs are by far the biggest source
+		builder := &audit.FilterBuilder{Stages: []string{"ResponseComplete"}, Namespaces: o.namespaces, After: after, Before: before}
+		filters, err := builder.Build()
+		if err != nil {
+			return nil, err
+		}
+		entries, err := auditEntries(found.auditLogs, filters)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, entries...)
+	}
+	if sources.Has(SourceEvent) {
+		entries, err := eventEntries(found.eventFiles)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, entries...)
+	}
+	if sources.Has(SourceClusterOperator) {
+		entries, err := clusterOperatorEntries(found.clusterOperators)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, entries...)
+	}
+	if sources.Has(SourcePodLog) {
+		entries, err := podLogEntries(found.podLogs)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, entries...)
+	}
+	return ret, nil
+}
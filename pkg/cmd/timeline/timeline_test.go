@@ -0,0 +1,94 @@
+package timeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func TestFilterEntries(t *testing.T) {
+	start := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	entries := []*Entry{
+		{Time: start.Add(3 * time.Second), Source: SourceAudit, Namespace: "foo", Message: "audit"},
+		{Time: start.Add(time.Second), Source: SourceEvent, Namespace: "foo", Message: "event"},
+		{Time: start.Add(3 * time.Second), Source: SourcePodLog, Namespace: "foo", Message: "podlog"},
+		{Time: start.Add(2 * time.Second), Source: SourceClusterOperator, Message: "clusteroperator"},
+		{Time: start.Add(2 * time.Second), Source: SourceEvent, Namespace: "bar", Message: "other namespace"},
+		{Time: start, Source: SourceEvent, Namespace: "foo", Message: "too early"},
+		{Time: start.Add(time.Minute), Source: SourceEvent, Namespace: "foo", Message: "too late"},
+	}
+
+	tests := []struct {
+		name       string
+		after      time.Time
+		before     time.Time
+		namespaces sets.String
+		expected   []string
+	}{
+		{
+			name:     "merged by time",
+			expected: []string{"too early", "event", "clusteroperator", "other namespace", "audit", "podlog", "too late"},
+		},
+		{
+			name:     "time window",
+			after:    start,
+			before:   start.Add(time.Minute),
+			expected: []string{"event", "clusteroperator", "other namespace", "audit", "podlog"},
+		},
+		{
+			name:       "namespace",
+			after:      start,
+			namespaces: sets.NewString("foo"),
+			expected:   []string{"event", "audit", "podlog", "too late"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := []string{}
+			for _, entry := range filterEntries(entries, test.after, test.before, test.namespaces) {
+				actual = append(actual, entry.Message)
+			}
+			if len(actual) != len(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, actual)
+			}
+			for i := range actual {
+				if actual[i] != test.expected[i] {
+					t.Fatalf("expected %v, got %v", test.expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestReadDirectoryTimeWindow(t *testing.T) {
+	root := t.TempDir()
+	auditLog := filepath.Join(root, "audit_logs", "kube-apiserver", "master-0-audit.log")
+	if err := os.MkdirAll(filepath.Dir(auditLog), 0755); err != nil {
+		t.Fatal(err)
+	}
+	lines := ""
+	for _, received := range []string{"2026-10-18T09:00:00.000000Z", "2026-10-18T10:00:00.000000Z", "2026-10-18T11:00:00.000000Z"} {
+		lines += `{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"` + received + `","stage":"ResponseComplete",` +
+			`"requestURI":"/api/v1/namespaces/foo/pods","verb":"list","user":{"username":"alice"},` +
+			`"requestReceivedTimestamp":"` + received + `","stageTimestamp":"` + received + `"}` + "\n"
+	}
+	if err := os.WriteFile(auditLog, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := NewTimelineOptions(genericclioptions.NewTestIOStreamsDiscard())
+	o.sources = []string{SourceAudit}
+	after := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	before := time.Date(2026, 10, 18, 10, 30, 0, 0, time.UTC)
+	entries, err := o.readDirectory(root, after, before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].Time.Equal(time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected only the request of 10:00, got %v", entries)
+	}
+}
@@ -0,0 +1,265 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// queryDirName is the directory of the query files, ~/.config/kubectl-dev_tool for the queries of a user and
+	// .kubectl-dev_tool in the current directory, or its closest parent that has one, for the queries of a project.
+	queryDirName  = "kubectl-dev_tool"
+	queryFileName = "queries.yaml"
+)
+
+// QueryFile is the content of a query file, eg.
+//
+//	queries:
+//	- name: gc-deployments
+//	  description: GC calls to deployments
+//	  command: audit
+//	  filters:
+//	    user: system:serviceaccount:kube-system:generic-garbage-collector
+//	    resource: [deployments.*]
+//	  output: top
+//	  by: verb
+type QueryFile struct {
+	Queries []NamedQuery `json:"queries"`
+}
+
+// NamedQuery is a saved combination of flags of the audit or the event command.
+type NamedQuery struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Command is the command the query is for, audit or event.
+	Command string `json:"command"`
+	// Filters are the values of the flags of the command by flag name, eg. failed-only: true or namespace: [a, b].
+	Filters map[string]QueryValues `json:"filters,omitempty"`
+	Output  string                 `json:"output,omitempty"`
+	By      string                 `json:"by,omitempty"`
+
+	// Source is the file the query was read from.
+	Source string `json:"-"`
+}
+
+// QueryValues are the values of a flag, a single value can be written as a scalar.
+type QueryValues []string
+
+func (v *QueryValues) UnmarshalJSON(data []byte) error {
+	items := []json.RawMessage{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		items = []json.RawMessage{data}
+	}
+
+	values := QueryValues{}
+	for _, item := range items {
+		value := ""
+		if err := json.Unmarshal(item, &value); err == nil {
+			values = append(values, value)
+			continue
+		}
+		// numbers and booleans are kept as written
+		var scalar interface{}
+		if err := json.Unmarshal(item, &scalar); err != nil {
+			return err
+		}
+		switch scalar.(type) {
+		case float64, bool:
+			values = append(values, string(item))
+		default:
+			return fmt.Errorf("unsupported value %s, only strings, numbers, booleans and lists of them are supported", string(item))
+		}
+	}
+	*v = values
+	return nil
+}
+
+// Args returns the query as command line flags.
+func (q *NamedQuery) Args() []string {
+	ret := []string{}
+	names := []string{}
+	for name := range q.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ret = append(ret, fmt.Sprintf("--%s=%s", name, strings.Join(q.Filters[name], ",")))
+	}
+	if len(q.Output) > 0 {
+		ret = append(ret, "--output="+q.Output)
+	}
+	if len(q.By) > 0 {
+		ret = append(ret, "--by="+q.By)
+	}
+	return ret
+}
+
+// Apply sets the flags of the query, the flags given on the command line win over the query.
+func (q *NamedQuery) Apply(flags *pflag.FlagSet) error {
+	values := map[string]QueryValues{}
+	for name, value := range q.Filters {
+		values[name] = value
+	}
+	if len(q.Output) > 0 {
+		values["output"] = QueryValues{q.Output}
+	}
+	if len(q.By) > 0 {
+		values["by"] = QueryValues{q.By}
+	}
+
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		flag := flags.Lookup(name)
+		if flag == nil || name == "query" {
+			return fmt.Errorf("query %q: %s has no --%s flag", q.Name, q.Command, name)
+		}
+		if flag.Changed {
+			continue
+		}
+
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			if err := sliceValue.Replace(values[name]); err != nil {
+				return fmt.Errorf("query %q: invalid --%s: %v", q.Name, name, err)
+			}
+			flag.Changed = true
+			continue
+		}
+		if len(values[name]) != 1 {
+			return fmt.Errorf("query %q: --%s takes a single value, got %v", q.Name, name, values[name])
+		}
+		if err := flags.Set(name, values[name][0]); err != nil {
+			return fmt.Errorf("query %q: invalid --%s: %v", q.Name, name, err)
+		}
+	}
+	return nil
+}
+
+// QueryFiles returns the query files that exist, the file of the user first and the file of the project last.
+func QueryFiles() ([]string, error) {
+	ret := []string{}
+	// ~/.config rather than os.UserConfigDir, which is ~/Library/Application Support on macOS
+	if home, err := os.UserHomeDir(); err == nil {
+		if userFile := filepath.Join(home, ".config", queryDirName, queryFileName); fileExists(userFile) {
+			ret = append(ret, userFile)
+		}
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if projectFile := filepath.Join(dir, "."+queryDirName, queryFileName); fileExists(projectFile) {
+			ret = append(ret, projectFile)
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return ret, nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// LoadQueryFile reads the queries of a file.
+func LoadQueryFile(path string) ([]NamedQuery, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &QueryFile{}
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("unable to read queries from %q: %v", path, err)
+	}
+	for i := range file.Queries {
+		query := &file.Queries[i]
+		query.Source = path
+		if len(query.Name) == 0 {
+			return nil, fmt.Errorf("%q: query %d has no name", path, i)
+		}
+		if query.Command != "audit" && query.Command != "event" {
+			return nil, fmt.Errorf("%q: query %q has unsupported command %q, available values are: [audit,event]", path, query.Name, query.Command)
+		}
+	}
+	return file.Queries, nil
+}
+
+// LoadQueries reads the queries of all the query files, a query of the project overrides the query of the user with
+// the same name and command.  The queries are sorted by command and name.
+func LoadQueries() ([]NamedQuery, error) {
+	files, err := QueryFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	type queryKey struct{ command, name string }
+	queries := map[queryKey]NamedQuery{}
+	for _, file := range files {
+		fileQueries, err := LoadQueryFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, query := range fileQueries {
+			queries[queryKey{command: query.Command, name: query.Name}] = query
+		}
+	}
+
+	ret := []NamedQuery{}
+	for _, query := range queries {
+		ret = append(ret, query)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Command != ret[j].Command {
+			return ret[i].Command < ret[j].Command
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, nil
+}
+
+// AddQueryFlag adds the --query flag of a command, ApplyQuery applies the query it selects.
+func AddQueryFlag(flags *pflag.FlagSet, query *string) {
+	flags.StringVar(query, "query", *query, "Apply the flags of a saved query, the flags on the command line win over the query. Queries are read from ~/.config/kubectl-dev_tool/queries.yaml and .kubectl-dev_tool/queries.yaml of the project, see the query list command.")
+}
+
+// ApplyQuery applies the query called name to the flags of command, the name of the command selects the query.
+func ApplyQuery(command *cobra.Command, name string) error {
+	if len(name) == 0 {
+		return nil
+	}
+	queries, err := LoadQueries()
+	if err != nil {
+		return err
+	}
+
+	available := []string{}
+	for i := range queries {
+		if queries[i].Command != command.Name() {
+			continue
+		}
+		if queries[i].Name == name {
+			return queries[i].Apply(command.Flags())
+		}
+		available = append(available, queries[i].Name)
+	}
+	return fmt.Errorf("unknown %s query %q, available queries are: [%s]", command.Name(), name, strings.Join(available, ","))
+}
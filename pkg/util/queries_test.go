@@ -0,0 +1,110 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/pflag"
+)
+
+func TestNamedQueryApply(t *testing.T) {
+	file := &QueryFile{}
+	err := yaml.Unmarshal([]byte(`
+queries:
+- name: failed-kube-system
+  command: audit
+  filters:
+    namespace: [kube-system, openshift-etcd]
+    user: admin
+    failed-only: true
+    http-status-code: [500, 503]
+  output: top
+  by: user
+`), file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var namespaces, users []string
+	var codes []int32
+	var failedOnly bool
+	var output, by string
+	flags := pflag.NewFlagSet("audit", pflag.ContinueOnError)
+	flags.StringSliceVar(&namespaces, "namespace", nil, "")
+	flags.StringSliceVar(&users, "user", nil, "")
+	flags.Int32SliceVar(&codes, "http-status-code", nil, "")
+	flags.BoolVar(&failedOnly, "failed-only", false, "")
+	flags.StringVarP(&output, "output", "o", "", "")
+	flags.StringVar(&by, "by", "", "")
+	if err := flags.Parse([]string{"--by=verb"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := file.Queries[0].Apply(flags); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(namespaces, []string{"kube-system", "openshift-etcd"}) {
+		t.Errorf("unexpected namespaces %v", namespaces)
+	}
+	if !reflect.DeepEqual(users, []string{"admin"}) {
+		t.Errorf("unexpected users %v", users)
+	}
+	if !reflect.DeepEqual(codes, []int32{500, 503}) {
+		t.Errorf("unexpected http status codes %v", codes)
+	}
+	if !failedOnly || output != "top" {
+		t.Errorf("unexpected failed-only=%v output=%q", failedOnly, output)
+	}
+	if by != "verb" {
+		t.Errorf("expected the command line to win over the query, got by=%q", by)
+	}
+
+	unknown := NamedQuery{Name: "unknown", Command: "audit", Filters: map[string]QueryValues{"verbs": {"get"}}}
+	if err := unknown.Apply(flags); err == nil {
+		t.Errorf("expected an error for an unknown flag")
+	}
+}
+
+func TestQueryFiles(t *testing.T) {
+	home := t.TempDir()
+	// the working directory is read with its symlinks resolved
+	project, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	userFile := filepath.Join(home, ".config", queryDirName, queryFileName)
+	projectFile := filepath.Join(project, "."+queryDirName, queryFileName)
+	for _, file := range []string{userFile, projectFile} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte("queries: []\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	workDir := filepath.Join(project, "sub")
+	if err := os.Mkdir(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("HOME", home)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	files, err := QueryFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{userFile, projectFile}; !reflect.DeepEqual(expected, files) {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+}
@@ -0,0 +1,180 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	clockTimeRegex    = regexp.MustCompile(`^([0-9]{1,2}):([0-9]{2})(:([0-9]{2}))?$`)
+	relativeTimeRegex = regexp.MustCompile(`^(start|end)?([+-].+)?$`)
+)
+
+// TimeSpan is the first and the last timestamp of the loaded data, clock times and relative times are resolved
+// against it.
+type TimeSpan struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Include grows the span to contain t.
+func (s *TimeSpan) Include(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if s.Start.IsZero() || t.Before(s.Start) {
+		s.Start = t
+	}
+	if s.End.IsZero() || t.After(s.End) {
+		s.End = t
+	}
+}
+
+// NeedsTimeSpan is true when the value is resolved against the loaded data.
+func NeedsTimeSpan(value string) bool {
+	_, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(value))
+	return err != nil
+}
+
+// ParseTime resolves a time flag.  It accepts:
+//   - RFC3339, eg. 2023-05-04T10:12:00Z
+//   - a UTC clock time HH:MM[:SS] on the date of the data, the first date of the data where the time is within the data
+//     wins, eg. 10:12 or 10:12:30
+//   - a duration relative to the start or the end of the data, eg. start+10m, end-5m, start, end.  A lone +10m is
+//     relative to the start and a lone -5m is relative to the end.
+func ParseTime(value string, span TimeSpan) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	if span.Start.IsZero() || span.End.IsZero() {
+		return time.Time{}, fmt.Errorf("%q is relative to the data, but there is no data", value)
+	}
+
+	if matches := clockTimeRegex.FindStringSubmatch(value); matches != nil {
+		hours, _ := strconv.Atoi(matches[1])
+		minutes, _ := strconv.Atoi(matches[2])
+		seconds := 0
+		if len(matches[4]) > 0 {
+			seconds, _ = strconv.Atoi(matches[4])
+		}
+		if hours > 23 || minutes > 59 || seconds > 59 {
+			return time.Time{}, fmt.Errorf("invalid time of day %q", value)
+		}
+		return anchorClockTime(hours, minutes, seconds, span), nil
+	}
+
+	matches := relativeTimeRegex.FindStringSubmatch(value)
+	if matches == nil || len(value) == 0 {
+		return time.Time{}, fmt.Errorf("invalid time %q: use RFC3339, HH:MM[:SS] or a duration relative to the data like start+10m or end-5m", value)
+	}
+	anchor, offset := matches[1], matches[2]
+	if len(anchor) == 0 {
+		anchor = "start"
+		if strings.HasPrefix(offset, "-") {
+			anchor = "end"
+		}
+	}
+	base := span.Start
+	if anchor == "end" {
+		base = span.End
+	}
+	if len(offset) == 0 {
+		return base, nil
+	}
+	duration, err := time.ParseDuration(offset)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: %v", value, err)
+	}
+	return base.Add(duration), nil
+}
+
+// anchorClockTime puts a clock time on the first day of the span where it falls within the span, or on the last day.
+func anchorClockTime(hours, minutes, seconds int, span TimeSpan) time.Time {
+	start, end := span.Start.UTC(), span.End.UTC()
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	for ; !day.After(end); day = day.AddDate(0, 0, 1) {
+		t := day.Add(time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second)
+		if !t.Before(start.Truncate(time.Second)) && !t.After(end) {
+			return t
+		}
+	}
+	return time.Date(end.Year(), end.Month(), end.Day(), hours, minutes, seconds, 0, time.UTC)
+}
+
+// TimeWindowOptions holds the flags that select a time window, they are shared by the audit and the event commands.
+type TimeWindowOptions struct {
+	After  string
+	Before string
+	Around string
+	Window time.Duration
+}
+
+func NewTimeWindowOptions() *TimeWindowOptions {
+	return &TimeWindowOptions{Window: 10 * time.Minute}
+}
+
+func (o *TimeWindowOptions) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.After, "after", o.After, "Filter result of search to only after a time: RFC3339, HH:MM[:SS] (UTC) on the date of the data, or relative to the data like start+10m or end-5m.")
+	flags.StringVar(&o.Before, "before", o.Before, "Filter result of search to only before a time: RFC3339, HH:MM[:SS] (UTC) on the date of the data, or relative to the data like start+10m or end-5m.")
+	flags.StringVar(&o.Around, "around", o.Around, "Filter result of search to only around a time, in the same formats as --after.  Use --window to change how far around.")
+	flags.DurationVar(&o.Window, "window", o.Window, "The time before and after --around to display.")
+}
+
+// Validate checks the syntax of the times, they can only be resolved once the data is loaded.
+func (o *TimeWindowOptions) Validate() error {
+	if len(o.Around) > 0 && (len(o.After) > 0 || len(o.Before) > 0) {
+		return fmt.Errorf("--around cannot be combined with --after or --before")
+	}
+	if o.Window < 0 {
+		return fmt.Errorf("--window must not be negative")
+	}
+	// any span will do to check the syntax
+	now := time.Now()
+	for _, value := range []string{o.After, o.Before, o.Around} {
+		if len(value) == 0 {
+			continue
+		}
+		if _, err := ParseTime(value, TimeSpan{Start: now, End: now}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NeedsTimeSpan is true when one of the times is resolved against the loaded data.
+func (o *TimeWindowOptions) NeedsTimeSpan() bool {
+	for _, value := range []string{o.After, o.Before, o.Around} {
+		if len(value) > 0 && NeedsTimeSpan(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ToTimeWindow resolves the flags against the span of the data.  A zero time means the window is open on that side.
+func (o *TimeWindowOptions) ToTimeWindow(span TimeSpan) (after time.Time, before time.Time, err error) {
+	if len(o.Around) > 0 {
+		around, err := ParseTime(o.Around, span)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return around.Add(-o.Window), around.Add(o.Window), nil
+	}
+	if len(o.After) > 0 {
+		if after, err = ParseTime(o.After, span); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if len(o.Before) > 0 {
+		if before, err = ParseTime(o.Before, span); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	return after, before, nil
+}
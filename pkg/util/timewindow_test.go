@@ -0,0 +1,56 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	mustParse := func(value string) time.Time {
+		ret, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ret
+	}
+	// a log spanning midnight
+	span := TimeSpan{Start: mustParse("2026-10-17T22:30:00Z"), End: mustParse("2026-10-18T01:15:00Z")}
+
+	tests := []struct {
+		value    string
+		expected time.Time
+		err      bool
+	}{
+		{value: "2026-10-18T00:10:00Z", expected: mustParse("2026-10-18T00:10:00Z")},
+		{value: "23:10", expected: mustParse("2026-10-17T23:10:00Z")},
+		{value: "00:10:30", expected: mustParse("2026-10-18T00:10:30Z")},
+		// outside of the log, on the last day
+		{value: "12:00", expected: mustParse("2026-10-18T12:00:00Z")},
+		{value: "start", expected: span.Start},
+		{value: "end", expected: span.End},
+		{value: "start+10m", expected: mustParse("2026-10-17T22:40:00Z")},
+		{value: "end-1h", expected: mustParse("2026-10-18T00:15:00Z")},
+		{value: "+1h", expected: mustParse("2026-10-17T23:30:00Z")},
+		{value: "-5m", expected: mustParse("2026-10-18T01:10:00Z")},
+		{value: "25:00", err: true},
+		{value: "end-soon", err: true},
+		{value: "yesterday", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			actual, err := ParseTime(test.value, span)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !actual.Equal(test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}